@@ -0,0 +1,36 @@
+package zulu
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	// ActiveHelpEnvVar is the environment variable which disables ActiveHelp
+	// messages for all programs when set to "0".
+	ActiveHelpEnvVar = "ZULU_ACTIVE_HELP"
+
+	// activeHelpMarker prefixes the completions which are ActiveHelp messages.
+	activeHelpMarker = "_activeHelp_ "
+)
+
+// AppendActiveHelp adds message to completions as an ActiveHelp message.
+// Instead of being completed, such messages are shown to the user by the
+// completion script, below the command line, e.g. to explain what is expected.
+// It can be called multiple times, each message being shown on its own line.
+//
+// ActiveHelp is shown by the bash and zsh completion scripts, and is ignored by
+// the other shells. Users can disable it by setting ZULU_ACTIVE_HELP=0.
+func AppendActiveHelp(completions []string, message string) []string {
+	return append(completions, activeHelpMarker+message)
+}
+
+// isActiveHelp returns true if comp is an ActiveHelp message.
+func isActiveHelp(comp string) bool {
+	return strings.HasPrefix(comp, activeHelpMarker)
+}
+
+// activeHelpDisabled returns true if the user disabled ActiveHelp messages.
+func activeHelpDisabled() bool {
+	return os.Getenv(ActiveHelpEnvVar) == "0"
+}
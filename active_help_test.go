@@ -0,0 +1,74 @@
+package zulu_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+const activeHelpMarker = "_activeHelp_ "
+
+func TestActiveHelp(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use: "root",
+		ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+			comps := zulu.AppendActiveHelp(nil, "This is an ActiveHelp message")
+			comps = append(comps, "first", "second")
+			comps = zulu.AppendActiveHelp(comps, "Another message")
+			return comps, zulu.ShellCompDirectiveNoFileComp
+		},
+		RunE: noopRun,
+	}
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		activeHelpMarker + "This is an ActiveHelp message",
+		"first",
+		"second",
+		activeHelpMarker + "Another message",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+
+	t.Setenv(zulu.ActiveHelpEnvVar, "0")
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"first",
+		"second",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestActiveHelpInScripts(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+
+	testCases := []struct {
+		shell string
+		gen   func(*bytes.Buffer) error
+	}{
+		{"bash", func(buf *bytes.Buffer) error { return rootCmd.GenBashCompletion(buf, true) }},
+		{"zsh", func(buf *bytes.Buffer) error { return rootCmd.GenZshCompletion(buf, true) }},
+		{"fish", func(buf *bytes.Buffer) error { return rootCmd.GenFishCompletion(buf, true) }},
+		{"powershell", func(buf *bytes.Buffer) error { return rootCmd.GenPowershellCompletion(buf, true) }},
+		{"nushell", func(buf *bytes.Buffer) error { return rootCmd.GenNushellCompletion(buf, true) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.shell, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			testutil.AssertNil(t, tc.gen(buf))
+			testutil.AssertContains(t, buf.String(), activeHelpMarker)
+		})
+	}
+}
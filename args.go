@@ -1,30 +1,73 @@
 package zulu
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 )
 
 type PositionalArgs func(cmd *Command, args []string) error
 
+// unknownCommandError reports that name is not a subcommand of the command,
+// see Command.SetUnknownCommandFunc. args are the arguments following name.
+type unknownCommandError struct {
+	name string
+	args []string
+	msg  string
+}
+
+func (e *unknownCommandError) Error() string {
+	return e.msg
+}
+
+// validateArgs returns an error if any of args is not in
 // the `ValidArgs` field of `Command`.
 func validateArgs(cmd *Command, args []string) error {
 	if len(cmd.ValidArgs) > 0 {
-		// Remove any description that may be included in ValidArgs.
-		// A description is following a tab character.
-		var validArgs []string
-		for _, v := range cmd.ValidArgs {
-			validArgs = append(validArgs, strings.Split(v, "\t")[0])
-		}
+		validArgs := validArgNames(cmd)
 		for _, v := range args {
 			if !stringInSlice(v, validArgs) {
-				return fmt.Errorf("invalid argument %q for %q%s", v, cmd.CommandPath(), cmd.findSuggestions(args[0]))
+				return fmt.Errorf("invalid argument %q for %q%s", v, cmd.CommandPath(), cmd.findSuggestions(v))
 			}
 		}
 	}
 	return nil
 }
 
+// validateArgsOrAliases returns an error listing the valid arguments if any of
+// args is not in the `ValidArgs` field of `Command`, nor in its `ArgAliases`.
+func validateArgsOrAliases(cmd *Command, args []string) error {
+	if len(cmd.ValidArgs) > 0 {
+		validArgs := validArgNames(cmd)
+		for _, v := range args {
+			if !stringInSlice(v, validArgs) && !stringInSlice(v, cmd.ArgAliases) {
+				return fmt.Errorf("invalid argument %q for %q, must be one of %v%s",
+					v, cmd.CommandPath(), validArgs, cmd.findSuggestions(v))
+			}
+		}
+	}
+	return nil
+}
+
+// validArgNames returns the `ValidArgs` of `Command` without their description.
+func validArgNames(cmd *Command) []string {
+	// Remove any description that may be included in ValidArgs.
+	// A description is following a tab character.
+	validArgs := make([]string, 0, len(cmd.ValidArgs))
+	for _, v := range cmd.ValidArgs {
+		validArgs = append(validArgs, strings.Split(v, "\t")[0])
+	}
+	return validArgs
+}
+
+// checksValidArgs reports whether args is OnlyValidArgs or ExactValidArgs, which
+// check the `ValidArgs` of `Command` themselves, so that they are not checked first.
+func checksValidArgs(args PositionalArgs) bool {
+	fn := reflect.ValueOf(args).Pointer()
+	return fn == reflect.ValueOf(OnlyValidArgs).Pointer() || fn == reflect.ValueOf(ExactValidArgs(0)).Pointer()
+}
+
 // - subcommands will always accept arbitrary arguments.
 func legacyArgs(cmd *Command, args []string) error {
 	// no subcommand, always take args
@@ -34,7 +77,11 @@ func legacyArgs(cmd *Command, args []string) error {
 
 	// root command with subcommands, do subcommand checking.
 	if len(args) > 0 && !cmd.HasParent() {
-		return fmt.Errorf("unknown command %q for %q%s", args[0], cmd.CommandPath(), cmd.findSuggestions(args[0]))
+		return &unknownCommandError{
+			name: args[0],
+			args: args[1:],
+			msg:  fmt.Sprintf("unknown command %q for %q%s", args[0], cmd.CommandPath(), cmd.findSuggestions(args[0])),
+		}
 	}
 	return nil
 }
@@ -42,7 +89,11 @@ func legacyArgs(cmd *Command, args []string) error {
 // NoArgs returns an error if any args are included.
 func NoArgs(cmd *Command, args []string) error {
 	if len(args) > 0 {
-		return fmt.Errorf("unknown command %q for %q", args[0], cmd.CommandPath())
+		return &unknownCommandError{
+			name: args[0],
+			args: args[1:],
+			msg:  fmt.Sprintf("unknown command %q for %q", args[0], cmd.CommandPath()),
+		}
 	}
 	return nil
 }
@@ -52,6 +103,12 @@ func ArbitraryArgs(cmd *Command, args []string) error {
 	return nil
 }
 
+// OnlyValidArgs returns an error if any of the args is not in the ValidArgs
+// field of Command, nor in its ArgAliases, regardless of the number of args.
+func OnlyValidArgs(cmd *Command, args []string) error {
+	return validateArgsOrAliases(cmd, args)
+}
+
 // MinimumNArgs returns an error if there is not at least N args.
 func MinimumNArgs(n int) PositionalArgs {
 	return func(cmd *Command, args []string) error {
@@ -82,6 +139,18 @@ func ExactArgs(n int) PositionalArgs {
 	}
 }
 
+// ExactValidArgs returns an error if there are not exactly n args, and then if
+// any of them is not in the ValidArgs field of Command, listing the valid ones.
+// The ArgAliases of Command are accepted as well.
+func ExactValidArgs(n int) PositionalArgs {
+	return func(cmd *Command, args []string) error {
+		if err := ExactArgs(n)(cmd, args); err != nil {
+			return err
+		}
+		return validateArgsOrAliases(cmd, args)
+	}
+}
+
 // RangeArgs returns an error if the number of args is not within the expected range.
 //
 //nolint:predeclared // no real alternative names for min
@@ -105,3 +174,19 @@ func MatchAll(pargs ...PositionalArgs) PositionalArgs {
 		return nil
 	}
 }
+
+// MatchAny allows combining several PositionalArgs, of which at least one must pass.
+// If none passes, the errors of all of them are returned.
+func MatchAny(pargs ...PositionalArgs) PositionalArgs {
+	return func(cmd *Command, args []string) error {
+		errs := make([]error, 0, len(pargs))
+		for _, parg := range pargs {
+			err := parg(cmd, args)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+}
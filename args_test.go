@@ -27,6 +27,9 @@ func TestArgs(t *testing.T) {
 		"Arbitrary/Valid | Valid":   {"", zulu.ArbitraryArgs, true, []string{"one", "two"}},
 		"Arbitrary/Valid | Invalid": {"invalid", zulu.ArbitraryArgs, true, []string{"a"}},
 
+		"OnlyValid/Valid | Valid":   {"", zulu.OnlyValidArgs, true, []string{"one", "two", "one"}},
+		"OnlyValid/Valid | Invalid": {"notvalid", zulu.OnlyValidArgs, true, []string{"one", "a"}},
+
 		"MinimumN/      | Arb":         {"", zulu.MinimumNArgs(2), false, []string{"a", "b", "c"}},
 		"MinimumN/Valid | Valid":       {"", zulu.MinimumNArgs(2), true, []string{"one", "three"}},
 		"MinimumN/Valid | Invalid":     {"invalid", zulu.MinimumNArgs(2), true, []string{"a", "b"}},
@@ -48,6 +51,11 @@ func TestArgs(t *testing.T) {
 		"Exact/Valid | InvalidCount":        {"notexact", zulu.ExactArgs(2), true, []string{"three", "one", "two"}},
 		"Exact/Valid | InvalidCountInvalid": {"invalid", zulu.ExactArgs(2), true, []string{"three", "a", "two"}},
 
+		"ExactValid/Valid | Valid":               {"", zulu.ExactValidArgs(3), true, []string{"three", "one", "two"}},
+		"ExactValid/Valid | Invalid":             {"notvalid", zulu.ExactValidArgs(3), true, []string{"three", "a", "two"}},
+		"ExactValid/Valid | InvalidCount":        {"notexact", zulu.ExactValidArgs(2), true, []string{"three", "one", "two"}},
+		"ExactValid/Valid | InvalidCountInvalid": {"notexact", zulu.ExactValidArgs(2), true, []string{"three", "a", "two"}},
+
 		"Range/      | Arb":                 {"", zulu.RangeArgs(2, 4), false, []string{"a", "b", "c"}},
 		"Range/Valid | Valid":               {"", zulu.RangeArgs(2, 4), true, []string{"three", "one", "two"}},
 		"Range/Valid | Invalid":             {"invalid", zulu.RangeArgs(2, 4), true, []string{"three", "a", "two"}},
@@ -58,6 +66,7 @@ func TestArgs(t *testing.T) {
 
 	var errStrings = map[string]string{
 		"invalid":    `invalid argument "a" for "c"`,
+		"notvalid":   `invalid argument "a" for "c", must be one of [one two three]`,
 		"unknown":    `unknown command "one" for "c"`,
 		"less":       "requires at least 2 arg(s), only received 1",
 		"more":       "accepts at most 2 arg(s), received 3",
@@ -141,6 +150,63 @@ func TestChildTakesArgs(t *testing.T) {
 	testutil.AssertNilf(t, err, "Unexpected error")
 }
 
+func TestExactValidArgsWithAliases(t *testing.T) {
+	c := &zulu.Command{
+		Use:        "c",
+		Args:       zulu.ExactValidArgs(2),
+		ValidArgs:  []string{"one\tThe first", "two"},
+		ArgAliases: []string{"uno"},
+		RunE:       noopRun,
+	}
+
+	_, err := executeCommand(c, "uno", "two")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	_, err = executeCommand(c, "dos", "two")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertEqual(t, `invalid argument "dos" for "c", must be one of [one two]`, err.Error())
+}
+
+func TestValidArgsWithoutValidator(t *testing.T) {
+	c := &zulu.Command{
+		Use:        "c",
+		ValidArgs:  []string{"one", "two"},
+		ArgAliases: []string{"uno"},
+		RunE:       noopRun,
+	}
+
+	_, err := executeCommand(c, "uno")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertEqual(t, `invalid argument "uno" for "c"`, err.Error())
+}
+
+func TestValidArgsSuggestionsForInvalidArg(t *testing.T) {
+	c := &zulu.Command{Use: "c", ValidArgs: []string{"one", "two"}, RunE: noopRun}
+	c.AddCommand(&zulu.Command{Use: "sub", RunE: noopRun})
+
+	err := c.ValidateArgs([]string{"one", "sbu"})
+	testutil.AssertNotNilf(t, err, "Expected error")
+	expected := "invalid argument \"sbu\" for \"c\"\n\nDid you mean this?\n\tsub\n"
+	testutil.AssertEqual(t, expected, err.Error())
+}
+
+func TestOnlyValidArgsWithAliases(t *testing.T) {
+	c := &zulu.Command{
+		Use:        "c",
+		Args:       zulu.OnlyValidArgs,
+		ValidArgs:  []string{"one", "two"},
+		ArgAliases: []string{"uno"},
+		RunE:       noopRun,
+	}
+
+	_, err := executeCommand(c, "uno", "two", "one")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	_, err = executeCommand(c, "x")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertEqual(t, `invalid argument "x" for "c", must be one of [one two]`, err.Error())
+}
+
 func TestMatchAll(t *testing.T) {
 	// Somewhat contrived example check that ensures there are exactly 3
 	// arguments, and each argument is exactly 2 bytes long.
@@ -188,6 +254,56 @@ func TestMatchAll(t *testing.T) {
 	}
 }
 
+func TestMatchAny(t *testing.T) {
+	// Either no arguments, or exactly 2 arguments each of exactly 2 bytes.
+	pargs := zulu.MatchAny(
+		zulu.NoArgs,
+		zulu.MatchAll(
+			zulu.ExactArgs(2),
+			func(cmd *zulu.Command, args []string) error {
+				for _, arg := range args {
+					if len([]byte(arg)) != 2 {
+						return errors.New("expected to be exactly 2 bytes long")
+					}
+				}
+				return nil
+			},
+		),
+	)
+
+	testCases := map[string]struct {
+		args []string
+		fail bool
+	}{
+		"first check passes": {
+			[]string{},
+			false,
+		},
+		"second check passes": {
+			[]string{"aa", "bb"},
+			false,
+		},
+		"no check passes": {
+			[]string{"aa", "abc"},
+			true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rootCmd := &zulu.Command{Use: "root", Args: pargs, RunE: noopRun}
+			_, err := executeCommand(rootCmd, tc.args...)
+			if !tc.fail {
+				testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			} else {
+				testutil.AssertNotNilf(t, err, "Expected an error")
+				testutil.AssertContains(t, err.Error(), `unknown command "aa" for "root"`)
+				testutil.AssertContains(t, err.Error(), "expected to be exactly 2 bytes long")
+			}
+		})
+	}
+}
+
 // This test make sure we keep backwards-compatibility with respect
 // to the legacyArgs() function.
 // It makes sure the root command accepts arguments if it does not have
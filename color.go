@@ -0,0 +1,99 @@
+package zulu
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"text/template"
+)
+
+// ColorMode controls whether the usage and help of a command are colored, see
+// Command.SetColorMode.
+type ColorMode int
+
+const (
+	// ColorModeAuto colors the output when it is written to a terminal and
+	// the NO_COLOR environment variable is not set.
+	ColorModeAuto ColorMode = iota + 1
+	// ColorModeAlways always colors the output.
+	ColorModeAlways
+	// ColorModeNever never colors the output.
+	ColorModeNever
+)
+
+const (
+	ansiBold  = "\x1b[1m"
+	ansiCyan  = "\x1b[36m"
+	ansiReset = "\x1b[0m"
+)
+
+// flagNamesRe matches the names of the flags at the start of the lines
+// rendered by zflag, e.g. "-h, --help" or "--verbose".
+var flagNamesRe = regexp.MustCompile(`(?m)^(\s+)(-[^\s,]+(?:, --[^\s=]+)?)`)
+
+// SetColorMode sets whether the usage and help of the command and its children
+// are colored with the bold, cyan and colorFlags template functions.
+func (c *Command) SetColorMode(mode ColorMode) {
+	c.colorMode = mode
+}
+
+// ColorMode returns the color mode of the command, as set on the command or
+// its closest parent, ColorModeAuto by default.
+func (c *Command) ColorMode() ColorMode {
+	for p := c; p != nil; p = p.Parent() {
+		if p.colorMode != 0 {
+			return p.colorMode
+		}
+	}
+	return ColorModeAuto
+}
+
+// colorEnabled reports whether the usage and help of the command written to w
+// are colored.
+func (c *Command) colorEnabled(w io.Writer) bool {
+	switch c.ColorMode() {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	default:
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return false
+		}
+		return isTerminal(w)
+	}
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorTemplateFuncs returns the coloring template functions of the command
+// for the output written to w, which leave the text as is when the colors
+// are disabled.
+func (c *Command) colorTemplateFuncs(w io.Writer) template.FuncMap {
+	if !c.colorEnabled(w) {
+		return template.FuncMap{
+			"bold":       noColor,
+			"cyan":       noColor,
+			"colorFlags": noColor,
+		}
+	}
+	return template.FuncMap{
+		"bold": func(s string) string { return ansiBold + s + ansiReset },
+		"cyan": func(s string) string { return ansiCyan + s + ansiReset },
+		"colorFlags": func(s string) string {
+			return flagNamesRe.ReplaceAllString(s, "${1}"+ansiCyan+"${2}"+ansiReset)
+		},
+	}
+}
+
+func noColor(s string) string {
+	return s
+}
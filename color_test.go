@@ -0,0 +1,58 @@
+package zulu_test
+
+import (
+	"testing"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestSetColorMode(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun, CompletionOptions: zulu.CompletionOptions{DisableDefaultCmd: true}}
+	rootCmd.Flags().Bool("verbose", false, "verbose output", zflag.OptShorthand('v'))
+	childCmd := &zulu.Command{Use: "child", Short: "child short", RunE: noopRun}
+	childCmd.Flags().String("name", "", "name usage")
+	rootCmd.AddCommand(childCmd)
+
+	plain := `Usage:
+  root [flags]
+  root [command]
+
+Available Commands:
+  child       child short
+
+Flags:
+  -v, --verbose   verbose output
+
+Use "root [command] --help" for more information about a command.
+`
+
+	// Output which is not a terminal is not colored by default
+	testutil.AssertEqual(t, zulu.ColorModeAuto, rootCmd.ColorMode())
+	testutil.AssertEqual(t, plain, rmCarriageRet(rootCmd.UsageString()))
+
+	rootCmd.SetColorMode(zulu.ColorModeAlways)
+	expected := "\x1b[1mUsage:\x1b[0m\n" +
+		"  root [flags]\n" +
+		"  root [command]\n" +
+		"\n" +
+		"\x1b[1mAvailable Commands:\x1b[0m\n" +
+		"  \x1b[36mchild      \x1b[0m child short\n" +
+		"\n" +
+		"\x1b[1mFlags:\x1b[0m\n" +
+		"  \x1b[36m-v, --verbose\x1b[0m   verbose output\n" +
+		"\n" +
+		"Use \"root [command] --help\" for more information about a command.\n"
+	testutil.AssertEqual(t, expected, rmCarriageRet(rootCmd.UsageString()))
+
+	// The mode is inherited
+	testutil.AssertEqual(t, zulu.ColorModeAlways, childCmd.ColorMode())
+	testutil.AssertContains(t, childCmd.UsageString(), "  \x1b[36m--name\x1b[0m string   name usage")
+
+	childCmd.SetColorMode(zulu.ColorModeNever)
+	testutil.AssertNotContains(t, childCmd.UsageString(), "\x1b[")
+
+	rootCmd.SetColorMode(zulu.ColorModeNever)
+	testutil.AssertEqual(t, plain, rmCarriageRet(rootCmd.UsageString()))
+}
@@ -22,9 +22,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"os/signal"
+	"reflect"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	texttemplate "text/template"
+	"time"
 
 	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2/internal/template"
@@ -33,16 +42,112 @@ import (
 
 const FlagSetByZuluAnnotation = "zulu_annotation_flag_set_by_zulu"
 
+// CommandSetByZuluAnnotation is the annotation set on the commands zulu adds by
+// default, such as the help and completion commands.
+const CommandSetByZuluAnnotation = "zulu_annotation_command_set_by_zulu"
+
 //go:embed templates/*
 var tmplFS embed.FS
 
 // FParseErrAllowList configures Flag parse errors to be ignored.
 type FParseErrAllowList zflag.ParseErrorsAllowList
 
+// The sections of the default usage template, see Command.SetUsageSectionOrder.
+const (
+	UsageSectionAliases     = "aliases"
+	UsageSectionExamples    = "examples"
+	UsageSectionCommands    = "commands"
+	UsageSectionFlags       = "flags"
+	UsageSectionGlobalFlags = "global_flags"
+	UsageSectionHelpTopics  = "help_topics"
+)
+
+// The phases of the execution of a command reported to the function set with
+// SetExecutionObserver.
+const (
+	ExecutionPhaseInitialize = "Initialize"
+	ExecutionPhaseParseFlags = "ParseFlags"
+	ExecutionPhasePreRun     = "PreRun"
+	ExecutionPhaseRun        = "Run"
+	ExecutionPhasePostRun    = "PostRun"
+	ExecutionPhaseFinalize   = "Finalize"
+)
+
 // ErrVersion is the error returned if the flag -version is invoked.
 var ErrVersion = errors.New("zulu: version requested")
 
+// ErrHelpRequested is the error returned by ExecuteC once the help requested
+// with the help flag has been printed, if ReturnErrOnHelp or PropagateSentinelErrors
+// is set. It wraps zflag.ErrHelp, so it can be matched with either of them.
+var ErrHelpRequested error = helpRequestedError{}
+
+// helpRequestedError is the type of ErrHelpRequested.
+type helpRequestedError struct{}
+
+func (helpRequestedError) Error() string { return "zulu: help requested" }
+func (helpRequestedError) Unwrap() error { return zflag.ErrHelp }
+
+// ErrSubcommandRequired is the error returned when a command setting
+// RequireSubcommand is invoked without one of its subcommands.
+var ErrSubcommandRequired = errors.New("a subcommand is required")
+
+// ErrShowHelp can be returned by the RunE-style functions of a command to
+// have ExecuteC print the help of the command, and return it as a usage error.
+var ErrShowHelp = errors.New("zulu: incomplete invocation, help shown")
+
+// ExitCodeError is implemented by the errors that carry the exit code
+// ExecuteWithExitCode returns for them.
+type ExitCodeError interface {
+	error
+	ExitCode() int
+}
+
+// commandContextKey is the context key of the command being executed.
+type commandContextKey struct{}
+
+// commandContext is the context of an executed command, deriving from the
+// context given by the caller, see CommandFromContext.
+type commandContext struct {
+	context.Context
+	cmd *Command
+}
+
+func (c *commandContext) Value(key any) any {
+	if key == (commandContextKey{}) {
+		return c.cmd
+	}
+	return c.Context.Value(key)
+}
+
+// withCommand returns the context of cmd derived from ctx, replacing the
+// command ctx carries when it was already derived by a previous execution.
+func withCommand(ctx context.Context, cmd *Command) context.Context {
+	if cc, ok := ctx.(*commandContext); ok {
+		ctx = cc.Context
+	}
+	return &commandContext{Context: ctx, cmd: cmd}
+}
+
 type HookFuncE func(cmd *Command, args []string) error
+
+// SuggestionFn returns the suggestions for the typed name among the
+// candidates, see SetSuggestionFunc.
+type SuggestionFn func(typed string, candidates []string) []string
+
+// UnknownCommandFn handles the unknown subcommand name of cmd, given the
+// arguments following it, see SetUnknownCommandFunc.
+type UnknownCommandFn func(cmd *Command, name string, args []string) error
+
+// ExecutionObserverFn is called with the duration of each phase of the
+// execution of a command, see SetExecutionObserver.
+type ExecutionObserverFn func(cmd *Command, phase string, d time.Duration)
+
+// executionPhase is a named phase of the hooks execution chain, ending before
+// the hook at index end.
+type executionPhase struct {
+	name string
+	end  int
+}
 type HookFunc func(cmd *Command, args []string)
 
 // Group is a structure to manage groups for commands.
@@ -51,6 +156,21 @@ type Group struct {
 	Title string
 }
 
+// CommandExample is an example of how to use a command, see Command.Examples.
+type CommandExample struct {
+	// Command is the command line of the example.
+	Command string
+	// Description describes what the example does.
+	Description string
+}
+
+// GroupWithCommands is a group of commands along with the commands in it, see
+// Command.GroupsWithCommands.
+type GroupWithCommands struct {
+	Group    Group
+	Commands []*Command
+}
+
 // Command is just that, a command for your application.
 // E.g.  'go run ...' - 'run' is the command. Zulu requires
 // you to define the usage and description as part of your command
@@ -80,18 +200,33 @@ type Command struct {
 	// The group under which the command is grouped in the 'help' output.
 	Group string
 
+	// DefaultCommandGroup is the group assigned by AddCommand to the children
+	// added without a group, including the help and completion commands.
+	DefaultCommandGroup string
+
 	// Long is the long message shown in the 'help <this-command>' output.
 	Long string
 
 	// Example is examples of how to use the command.
 	Example string
 
+	// Examples are examples of how to use the command, each with a description.
+	// They are rendered instead of Example when set.
+	Examples []CommandExample
+
+	// VerifyExamples makes Validate check that each line of the examples invoking the program
+	// only references commands and flags that are defined.
+	VerifyExamples bool
+
 	// ValidArgs is list of all valid non-flag arguments that are accepted in shell completions
 	ValidArgs []string
 	// ValidArgsFunction is an optional function that provides valid non-flag arguments for shell completion.
 	// It is a dynamic version of using ValidArgs.
-	// Only one of ValidArgs and ValidArgsFunction can be used for a command.
+	// Only one of ValidArgs and ValidArgsFunction can be used for a command, unless MergeValidArgs is set.
 	ValidArgsFunction func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective)
+	// MergeValidArgs completes both ValidArgs and the results of ValidArgsFunction, without duplicates,
+	// instead of only ValidArgs.
+	MergeValidArgs bool
 
 	// Expected arguments
 	Args PositionalArgs
@@ -112,6 +247,12 @@ type Command struct {
 	// group commands.
 	Annotations map[string]string
 
+	// InheritableAnnotations are key/value pairs like Annotations, which also
+	// apply to the children commands unless they set the same keys, e.g. for
+	// routing metadata. They are resolved through the parents when read, so the
+	// ones of the children are left as is. See ResolveAnnotation.
+	InheritableAnnotations map[string]string
+
 	// Version defines the version for this command. If this value is non-empty and the command does not
 	// define a "version" flag, a "version" boolean flag will be added to the command and, if specified,
 	// will print content of the "Version" variable. A shorthand "v" flag will also be added if the
@@ -174,9 +315,31 @@ type Command struct {
 	finalizeHooks []HookFuncE
 	// persistentFinalizeHooks: FinalizeE but children inherit and execute this too.
 	persistentFinalizeHooks []HookFuncE
+	// persistentFlagsParsedHooks are executed once the flags of the command or one of
+	// its children are parsed.
+	persistentFlagsParsedHooks []HookFuncE
+	// completionHooks are executed with the results of the completions requested for
+	// the command or one of its children.
+	completionHooks []CompletionHookFn
+
+	// cmdCompletionFilter filters the subcommands offered by shell completions.
+	cmdCompletionFilter CompletionCommandFilterFn
 
 	// groups for commands
 	commandGroups []Group
+	// groupTitleFunc is the group title func defined by the user.
+	groupTitleFunc func(groupID string) string
+	// flagGroupTitles are the titles of the flag groups defined by the user.
+	flagGroupTitles map[string]string
+
+	// usageSectionOrder is the order of the usage sections defined by the user.
+	usageSectionOrder []string
+
+	// usageForms are the usage forms added with AddUsageForm.
+	usageForms []string
+
+	// configFlag is the flag added by EnableConfigFlag.
+	configFlag *configFlag
 
 	// args is actual args parsed from flags.
 	args []string
@@ -201,15 +364,39 @@ type Command struct {
 	// flags and adjust completions taking into account these "relationships".
 	flagGroups []flagGroup
 
+	// flagOccurrences counts the number of times each flag was set by the last
+	// parsing of the flags.
+	flagOccurrences map[string]int
+
+	// flagDefaults restores the default value of the slice and map flags of the
+	// command tree, which can't be set back from their DefValue. It is only set
+	// on the root command.
+	flagDefaults map[*zflag.Flag]func() error
+	// resetFlagsErr is the error of the reset of the flags by SetArgs, returned
+	// by the next execution.
+	resetFlagsErr error
+
+	// cancelRunErr is the error set by CancelRunWithError during the current
+	// execution.
+	cancelRunErr error
+
 	// usageFunc is usage func defined by user.
 	usageFunc func(*Command) error
+	// flagUsageFormatter is the flag usage formatter defined by the user.
+	flagUsageFormatter func(flag *zflag.Flag) string
 	// usageTemplate is usage template defined by user.
 	usageTemplate string
+	// usageTemplateFuncs are the usage template functions defined by user.
+	usageTemplateFuncs texttemplate.FuncMap
 	// flagErrorFunc is func defined by user and it's called when the parsing of
 	// flags returns an error.
 	flagErrorFunc func(*Command, error) error
 	// helpTemplate is help template defined by user.
 	helpTemplate string
+	// helpTemplateFuncs are the help template functions defined by user.
+	helpTemplateFuncs texttemplate.FuncMap
+	// colorMode is the color mode of the usage and help defined by user.
+	colorMode ColorMode
 	// helpFunc is help func defined by user.
 	helpFunc func(*Command, []string)
 	// helpCommand is command with usage 'help'. If it's not defined by user,
@@ -221,6 +408,33 @@ type Command struct {
 	// versionTemplate is the version template defined by user.
 	versionTemplate string
 
+	// errPrefix is the error message prefix defined by user.
+	errPrefix string
+
+	// columnWidths are the minimum widths of the help columns defined by user.
+	columnWidths padding
+
+	// suggestionFunc is the function suggesting commands defined by user.
+	suggestionFunc SuggestionFn
+
+	// unknownCommandFunc is the function handling unknown commands defined by user.
+	unknownCommandFunc UnknownCommandFn
+
+	// externalCommandPrefix is the prefix of the external commands, set with
+	// EnableExternalCommands.
+	externalCommandPrefix string
+
+	// flagEnvPrefix is the prefix of the environment variables the flags are
+	// bound to, set with BindFlagsToEnv.
+	flagEnvPrefix string
+
+	// flagSources are the sources of flag values added with BindFlags.
+	flagSources []FlagSourceFn
+
+	// observer is the function observing the execution of the command and its
+	// children, set with SetExecutionObserver.
+	observer ExecutionObserverFn
+
 	// inReader is a reader defined by the user that replaces stdin
 	inReader io.Reader
 	// outWriter is a writer defined by the user that replaces stdout
@@ -234,7 +448,10 @@ type Command struct {
 	// CompletionOptions is a set of options to control the handling of shell completion
 	CompletionOptions CompletionOptions
 
-	// commandsAreSorted defines, if command slice are sorted or not.
+	// sortedCommands holds the subcommands sorted by name, while commands keeps
+	// them in the order they were added.
+	sortedCommands []*Command
+	// commandsAreSorted defines, if sortedCommands is up-to-date or not.
 	commandsAreSorted bool
 	// commandCalledAs is the name or alias value used to call this command.
 	commandCalledAs struct {
@@ -261,10 +478,53 @@ type Command struct {
 	// SilenceUsage is an option to silence usage when an error occurs.
 	SilenceUsage bool
 
+	// PrefixMatching overrides EnablePrefixMatching for the command and its
+	// children, unless they set it too. If nil, EnablePrefixMatching is used.
+	PrefixMatching *bool
+
+	// RequireSubcommand makes invoking the command without one of its
+	// subcommands an error, ErrSubcommandRequired, rather than showing its
+	// help. It only applies to commands that are not runnable.
+	RequireSubcommand bool
+
+	// ReturnErrOnHelp makes ExecuteC return ErrHelpRequested instead of nil
+	// once the help requested with the help flag has been printed, so callers
+	// executing commands in-process can tell when only the help was shown.
+	// It is read on the root command.
+	ReturnErrOnHelp bool
+
+	// PropagateSentinelErrors makes ExecuteC return ErrVersion and
+	// ErrHelpRequested instead of nil once the version or the help has been
+	// printed, so that programs can exit with a distinct code. It implies
+	// ReturnErrOnHelp. It is read on the root command.
+	PropagateSentinelErrors bool
+
+	// EnableTrailingHelpWord shows the help of a command when "help" is the last
+	// argument, e.g. "root child help" for the help of child, like "root help child"
+	// does. It is read on the root command.
+	EnableTrailingHelpWord bool
+
+	// AutoResetFlagsOnSetArgs resets the flags of the command and its children
+	// to their default values when SetArgs is called, so that executing the
+	// command again does not carry over the flags of a previous execution. The
+	// slices and maps are set back to the values they had before being parsed
+	// when it is set on the root command, which also makes Complete reset them.
+	AutoResetFlagsOnSetArgs bool
+
 	// DisableFlagParsing disables the flag parsing.
 	// If this is true all flags will be passed to the command as arguments.
 	DisableFlagParsing bool
 
+	// Minimal keeps the surface of the command and its children minimal: no
+	// default help flag, help command nor completion command are added to them,
+	// and they are not offered by shell completions.
+	Minimal bool
+
+	// WrapRunErrors prefixes the errors returned by the run hooks of the command
+	// and its children with the path of the command, e.g. "root child: error".
+	// The original error can still be retrieved with errors.Is and errors.As.
+	WrapRunErrors bool
+
 	// DisableAutoGenTag defines, if gen tag ("Auto generated by zulucmd/zulu...")
 	// will be printed by generating docs for this command.
 	DisableAutoGenTag bool
@@ -280,6 +540,20 @@ type Command struct {
 	// SuggestionsMinimumDistance defines minimum levenshtein distance to display suggestions.
 	// Must be > 0.
 	SuggestionsMinimumDistance int
+
+	// MaxSuggestions limits the number of suggestions displayed for an unknown
+	// command, keeping the closest ones. A value <= 0 means no limit.
+	MaxSuggestions int
+}
+
+// CommandFromContext returns the command being executed, as stored in ctx
+// before running its hooks. The context of the executed command derives once
+// from the context given to ExecuteContext, and so holds all its values. This
+// allows shared hooks, such as a PersistentPreRunE, to know which leaf command
+// is running. It returns nil if ctx does not come from an executed command.
+func CommandFromContext(ctx context.Context) *Command {
+	cmd, _ := ctx.Value(commandContextKey{}).(*Command)
+	return cmd
 }
 
 // Context returns underlying command context. If command wasn't
@@ -298,8 +572,112 @@ func (c *Command) SetContext(ctx context.Context) {
 
 // SetArgs sets arguments for the command. It is set to os.Args[1:] by default, if desired, can be overridden
 // particularly useful when testing.
+//
+// SetArgs clears how the command and its children were called by a previous
+// execution, and resets their flags as well if AutoResetFlagsOnSetArgs is set.
+// If a flag can't be reset, the error is returned by the next execution.
 func (c *Command) SetArgs(a []string) {
 	c.args = a
+	c.resetFlagsErr = c.resetParseState(c.AutoResetFlagsOnSetArgs)
+}
+
+// resetParseState clears the state left by a previous execution on the command
+// and its children, including the changed flags if resetFlags is true.
+func (c *Command) resetParseState(resetFlags bool) error {
+	c.commandCalledAs.name = ""
+	c.commandCalledAs.called = false
+
+	var errs []error
+	if resetFlags {
+		reset := func(flag *zflag.Flag) {
+			if err := c.resetFlag(flag); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		c.Flags().VisitAll(reset)
+		c.PersistentFlags().VisitAll(reset)
+	}
+
+	for _, cmd := range c.commands {
+		errs = append(errs, cmd.resetParseState(resetFlags))
+	}
+	return errors.Join(errs...)
+}
+
+// resetFlag sets a changed flag back to its default value, the one recorded
+// before it was first parsed for slices and maps.
+func (c *Command) resetFlag(flag *zflag.Flag) error {
+	if !flag.Changed {
+		c.recordFlagDefault(flag)
+		return nil
+	}
+
+	var err error
+	if restore, ok := c.Root().flagDefaults[flag]; ok {
+		err = restore()
+	} else {
+		err = flag.Value.Set(flag.DefValue)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reset flag %q: %w", flag.Name, err)
+	}
+	flag.Changed = false
+	return nil
+}
+
+// recordFlagDefault records the current value of flag on the root command, to
+// restore it when the flag is reset, if the flag holds a slice or a map and
+// its value is not recorded yet. The DefValue of those can't be parsed back,
+// e.g. the elements of a slice may contain spaces.
+func (c *Command) recordFlagDefault(flag *zflag.Flag) {
+	root := c.Root()
+	if _, ok := root.flagDefaults[flag]; ok {
+		return
+	}
+
+	restore := defaultRestorer(flag.Value)
+	if restore == nil {
+		return
+	}
+	if root.flagDefaults == nil {
+		root.flagDefaults = map[*zflag.Flag]func() error{}
+	}
+	root.flagDefaults[flag] = restore
+}
+
+// defaultRestorer returns a function setting value back to its current value
+// through its own type, or nil if value holds neither a slice nor a map.
+func defaultRestorer(value zflag.Value) func() error {
+	if sv, ok := value.(zflag.SliceValue); ok {
+		def := slices.Clone(sv.GetSlice())
+		return func() error {
+			return sv.Replace(slices.Clone(def))
+		}
+	}
+
+	getter, ok := value.(zflag.Getter)
+	if !ok {
+		return nil
+	}
+	current := reflect.ValueOf(getter.Get())
+	if current.Kind() != reflect.Map {
+		return nil
+	}
+	def := reflect.MakeMapWithSize(current.Type(), current.Len())
+	for iter := current.MapRange(); iter.Next(); {
+		def.SetMapIndex(iter.Key(), iter.Value())
+	}
+	return func() error {
+		current := reflect.ValueOf(getter.Get())
+		if current.Kind() != reflect.Map || current.IsNil() {
+			return fmt.Errorf("value %s is not a map that can be restored", value)
+		}
+		current.Clear()
+		for iter := def.MapRange(); iter.Next(); {
+			current.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return nil
+	}
 }
 
 // SetOut sets the destination for usage messages.
@@ -320,16 +698,77 @@ func (c *Command) SetIn(newIn io.Reader) {
 	c.inReader = newIn
 }
 
+// SetInOutErr sets the source for input data, and the destinations for usage
+// and error messages at once. Each one being nil falls back to the one of the
+// parent command, or to os.Stdin, os.Stdout and os.Stderr respectively.
+func (c *Command) SetInOutErr(in io.Reader, out, err io.Writer) {
+	c.inReader = in
+	c.outWriter = out
+	c.errWriter = err
+}
+
 // SetUsageFunc sets usage function. Usage can be defined by application.
 func (c *Command) SetUsageFunc(f func(*Command) error) {
 	c.usageFunc = f
 }
 
+// SetFlagUsageFormatter sets the function rendering the usage line of each flag
+// in the usage, instead of the default formatting. The flags for which it returns
+// an empty string are not shown. It is inherited by the children commands.
+func (c *Command) SetFlagUsageFormatter(f func(flag *zflag.Flag) string) {
+	c.flagUsageFormatter = f
+}
+
 // SetUsageTemplate sets usage template. Can be defined by Application.
 func (c *Command) SetUsageTemplate(s string) {
 	c.usageTemplate = s
 }
 
+// SetUsageTemplateE is the same as SetUsageTemplate, but parses the template
+// first, and returns an error without setting it if it is malformed, e.g. to
+// catch typos at startup. The template functions it uses must be added first.
+func (c *Command) SetUsageTemplateE(s string) error {
+	if err := template.Check(s, c.mergedTemplateFuncs(c.OutOrStderr(), usageTemplateFuncsOf)); err != nil {
+		return fmt.Errorf("invalid usage template: %w", err)
+	}
+	c.usageTemplate = s
+	return nil
+}
+
+// SetUsageTemplateFuncs adds template functions available to the usage
+// template of the command and its children, e.g. to call a colorize function
+// from a custom usage template. They take precedence over the functions added
+// with AddTemplateFunc and by the parents of the command.
+func (c *Command) SetUsageTemplateFuncs(funcs texttemplate.FuncMap) {
+	c.usageTemplateFuncs = funcs
+}
+
+// SetUsageSectionOrder sets the order in which the sections of the default
+// usage template are rendered, between the usage line and the closing hint,
+// e.g. to render the examples before the commands. The sections left out are
+// not rendered. It is inherited by the children commands.
+func (c *Command) SetUsageSectionOrder(sections ...string) {
+	c.usageSectionOrder = sections
+}
+
+// UsageSectionOrder returns the order in which the sections of the default
+// usage template are rendered.
+func (c *Command) UsageSectionOrder() []string {
+	for p := c; p != nil; p = p.Parent() {
+		if p.usageSectionOrder != nil {
+			return p.usageSectionOrder
+		}
+	}
+	return []string{
+		UsageSectionAliases,
+		UsageSectionExamples,
+		UsageSectionCommands,
+		UsageSectionFlags,
+		UsageSectionGlobalFlags,
+		UsageSectionHelpTopics,
+	}
+}
+
 // SetFlagErrorFunc sets a function to generate an error when flag parsing
 // fails.
 func (c *Command) SetFlagErrorFunc(f func(*Command, error) error) {
@@ -360,11 +799,65 @@ func (c *Command) SetHelpTemplate(s string) {
 	c.helpTemplate = s
 }
 
+// SetHelpTemplateE is the same as SetHelpTemplate, but parses the template
+// first, and returns an error without setting it if it is malformed. The
+// template functions it uses must be added first.
+func (c *Command) SetHelpTemplateE(s string) error {
+	if err := template.Check(s, c.mergedTemplateFuncs(c.OutOrStdout(), helpTemplateFuncsOf)); err != nil {
+		return fmt.Errorf("invalid help template: %w", err)
+	}
+	c.helpTemplate = s
+	return nil
+}
+
+// SetHelpTemplateFuncs adds template functions available to the help template
+// of the command and its children. They take precedence over the functions
+// added with AddTemplateFunc and by the parents of the command.
+func (c *Command) SetHelpTemplateFuncs(funcs texttemplate.FuncMap) {
+	c.helpTemplateFuncs = funcs
+}
+
 // SetVersionTemplate sets version template to be used. Application can use it to set custom template.
 func (c *Command) SetVersionTemplate(s string) {
 	c.versionTemplate = s
 }
 
+// SetVersionTemplateE is the same as SetVersionTemplate, but parses the
+// template first, and returns an error without setting it if it is malformed.
+// The template functions it uses must be added first.
+func (c *Command) SetVersionTemplateE(s string) error {
+	if err := template.Check(s, templateFuncs); err != nil {
+		return fmt.Errorf("invalid version template: %w", err)
+	}
+	c.versionTemplate = s
+	return nil
+}
+
+// SetExecutionObserver sets a function called with the duration of each phase
+// of the execution of the command and its children, one of the ExecutionPhase
+// constants, e.g. to find out what makes a command slow. The durations include
+// all the hooks of the phase, such as the PreRunE-style functions of the
+// parents for ExecutionPhasePreRun. Passing nil removes it.
+func (c *Command) SetExecutionObserver(f ExecutionObserverFn) {
+	c.observer = f
+}
+
+// executionObserver returns the function set with SetExecutionObserver on the
+// command or its closest parent.
+func (c *Command) executionObserver() ExecutionObserverFn {
+	for p := c; p != nil; p = p.Parent() {
+		if p.observer != nil {
+			return p.observer
+		}
+	}
+	return nil
+}
+
+// SetErrPrefix sets error message prefix to be used. Application can use it to set custom prefix.
+func (c *Command) SetErrPrefix(s string) {
+	c.errPrefix = s
+}
+
 // SetGlobalNormalizationFunc sets a normalization function to all flag sets and also to child commands.
 // The user should not have a cyclic dependency on commands.
 func (c *Command) SetGlobalNormalizationFunc(n func(f *zflag.FlagSet, name string) zflag.NormalizedName) {
@@ -427,6 +920,63 @@ func (c *Command) getIn(def io.Reader) io.Reader {
 	return def
 }
 
+// ResolveAnnotation returns the value of the inheritable annotation key of the
+// command or of its closest parent having one, and whether one was found.
+func (c *Command) ResolveAnnotation(key string) (string, bool) {
+	for p := c; p != nil; p = p.Parent() {
+		if value, ok := p.InheritableAnnotations[key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// SetFlagGroupTitle sets the title rendered in the usage for the flags in
+// group, local and inherited alike, instead of the group itself. It is
+// inherited by the children commands, which can set their own title for the
+// same group.
+func (c *Command) SetFlagGroupTitle(group, title string) {
+	if c.flagGroupTitles == nil {
+		c.flagGroupTitles = make(map[string]string)
+	}
+	c.flagGroupTitles[group] = title
+}
+
+// FlagGroupTitle returns the title of the flag group to render in the usage,
+// as set by SetFlagGroupTitle on the command or its closest parent, or the
+// group itself otherwise.
+func (c *Command) FlagGroupTitle(group string) string {
+	for p := c; p != nil; p = p.Parent() {
+		if title, ok := p.flagGroupTitles[group]; ok {
+			return title
+		}
+	}
+	return group
+}
+
+// FlagUsagesForGroup returns the usage of the visible flags of fs in group, as
+// rendered by the flag usage formatter if one is set, or by zflag otherwise.
+func (c *Command) FlagUsagesForGroup(fs *zflag.FlagSet, group string) string {
+	var formatter func(flag *zflag.Flag) string
+	for p := c; p != nil && formatter == nil; p = p.Parent() {
+		formatter = p.flagUsageFormatter
+	}
+	if formatter == nil {
+		return fs.FlagUsagesForGroup(group)
+	}
+
+	var buf strings.Builder
+	fs.VisitAll(func(flag *zflag.Flag) {
+		if flag.Hidden || flag.Group != group {
+			return
+		}
+		if line := formatter(flag); line != "" {
+			buf.WriteString(line + "\n")
+		}
+	})
+	return buf.String()
+}
+
 // UsageFunc returns either the function set by SetUsageFunc for this command
 // or a parent, or it returns a default usage function.
 func (c *Command) UsageFunc() func(*Command) error {
@@ -437,8 +987,7 @@ func (c *Command) UsageFunc() func(*Command) error {
 		return c.Parent().UsageFunc()
 	}
 	return func(c *Command) error {
-		c.mergePersistentFlags()
-		err := template.Parse(c.OutOrStderr(), c.UsageTemplate(), c, templateFuncs)
+		err := c.WriteUsage(c.OutOrStderr())
 		if err != nil {
 			c.PrintErrln(err)
 		}
@@ -446,6 +995,51 @@ func (c *Command) UsageFunc() func(*Command) error {
 	}
 }
 
+// usageMutex serializes the rendering of usage templates, as rendering
+// lazily initializes the flag sets of the commands.
+var usageMutex = &sync.Mutex{}
+
+// WriteUsage renders the usage template of the command to w.
+// Unlike Usage, it neither uses nor modifies the writers of the command, and
+// ignores any function set by SetUsageFunc, which makes it safe to be called
+// concurrently.
+func (c *Command) WriteUsage(w io.Writer) error {
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+
+	c.mergePersistentFlags()
+	return template.Parse(w, c.UsageTemplate(), c, c.mergedTemplateFuncs(w, usageTemplateFuncsOf))
+}
+
+func usageTemplateFuncsOf(c *Command) texttemplate.FuncMap {
+	return c.usageTemplateFuncs
+}
+
+func helpTemplateFuncsOf(c *Command) texttemplate.FuncMap {
+	return c.helpTemplateFuncs
+}
+
+// mergedTemplateFuncs returns the coloring and wrapping functions of the command
+// for the output written to w, overridden by the global template functions, and then by the ones returned by funcs
+// for the parents of the command and the command itself, the closest command taking
+// precedence.
+func (c *Command) mergedTemplateFuncs(
+	w io.Writer,
+	funcs func(*Command) texttemplate.FuncMap,
+) texttemplate.FuncMap {
+	merged := c.colorTemplateFuncs(w)
+	maps.Copy(merged, c.wrapTemplateFuncs(w))
+	maps.Copy(merged, templateFuncs)
+	var path []*Command
+	for p := c; p != nil; p = p.Parent() {
+		path = append(path, p)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		maps.Copy(merged, funcs(path[i]))
+	}
+	return merged
+}
+
 // Usage puts out the usage for the command.
 // Used when a user provides invalid input.
 // Can be defined by user by overriding UsageFunc.
@@ -466,7 +1060,8 @@ func (c *Command) HelpFunc() func(*Command, []string) {
 		c.mergePersistentFlags()
 		// The help should be sent to stdout
 		// See https://github.com/spf13/cobra/issues/1002
-		err := template.Parse(c.OutOrStdout(), c.HelpTemplate(), c, templateFuncs)
+		w := c.OutOrStdout()
+		err := template.Parse(w, c.HelpTemplate(), c, c.mergedTemplateFuncs(w, helpTemplateFuncsOf))
 		if err != nil {
 			c.PrintErrln(err)
 		}
@@ -533,13 +1128,45 @@ type padding struct {
 	Name        int
 }
 
+// SetColumnWidths sets the minimum widths of the usage, command path, and name
+// columns used to align the subcommands in the help of the command and its
+// children, in place of the default ones. The columns are still widened to fit
+// the longest subcommand. Zero keeps the default width of a column.
+func (c *Command) SetColumnWidths(usage, cmdPath, name int) {
+	c.columnWidths = padding{Usage: usage, CommandPath: cmdPath, Name: name}
+}
+
+// minPadding returns the minimum padding for the usage, command path, and name,
+// as set with SetColumnWidths on the command or its closest parent.
+func (c *Command) minPadding() padding {
+	p := padding{}
+	for x := c; x != nil; x = x.Parent() {
+		if p.Usage == 0 {
+			p.Usage = x.columnWidths.Usage
+		}
+		if p.CommandPath == 0 {
+			p.CommandPath = x.columnWidths.CommandPath
+		}
+		if p.Name == 0 {
+			p.Name = x.columnWidths.Name
+		}
+	}
+
+	if p.Usage == 0 {
+		p.Usage = minUsagePadding
+	}
+	if p.CommandPath == 0 {
+		p.CommandPath = minCommandPathPadding
+	}
+	if p.Name == 0 {
+		p.Name = minNamePadding
+	}
+	return p
+}
+
 // Padding return padding for the usage, command path, and name.
 func (c *Command) Padding() padding {
-	p := padding{
-		Usage:       minUsagePadding,
-		CommandPath: minCommandPathPadding,
-		Name:        minNamePadding,
-	}
+	p := c.minPadding()
 
 	if c.parent == nil {
 		return p
@@ -593,7 +1220,7 @@ func (c *Command) HelpTemplate() string {
 	if c.HasParent() {
 		return c.parent.HelpTemplate()
 	}
-	return `{{with (or .Long .Short)}}{{. | trimTrailingWhitespaces}}
+	return `{{with (or .Long .Short)}}{{. | trimTrailingWhitespaces | wrap 0}}
 
 {{end}}{{if or .Runnable .HasSubCommands}}{{.UsageString}}{{end}}`
 }
@@ -611,6 +1238,18 @@ func (c *Command) VersionTemplate() string {
 `
 }
 
+// ErrPrefix return error message prefix for the command.
+func (c *Command) ErrPrefix() string {
+	if c.errPrefix != "" {
+		return c.errPrefix
+	}
+
+	if c.HasParent() {
+		return c.parent.ErrPrefix()
+	}
+	return "Error:"
+}
+
 func isBoolFlag(name string, fs *zflag.FlagSet) bool {
 	flag := fs.Lookup(name)
 	if flag == nil {
@@ -621,18 +1260,27 @@ func isBoolFlag(name string, fs *zflag.FlagSet) bool {
 	return isBool
 }
 
-func isShortBoolFlag(name string, fs *zflag.FlagSet) bool {
-	if len(name) == 0 {
+// shortFlagsTakeValue reports whether a series of shorthand flags, such as the
+// "bs" in "-bs", ends with a flag whose value is the next argument.
+func shortFlagsTakeValue(shorthands string, fs *zflag.FlagSet) bool {
+	if len(shorthands) == 0 || strings.HasPrefix(shorthands, "-") {
 		return false
 	}
 
-	flag := fs.ShorthandLookupStr(name[:1])
-	if flag == nil {
-		return false
+	chars := []rune(shorthands)
+	for i, char := range chars {
+		flag := fs.ShorthandLookup(char)
+		if flag == nil {
+			return i == len(chars)-1
+		}
+
+		// Any characters following a non-bool flag are its value.
+		if _, isBool := flag.Value.(zflag.BoolFlag); !isBool {
+			return i == len(chars)-1
+		}
 	}
 
-	_, isBool := flag.Value.(zflag.BoolFlag)
-	return isBool
+	return false
 }
 
 func stripFlags(args []string, c *Command) []string {
@@ -656,7 +1304,7 @@ Loop:
 			// If '--flag arg' then
 			// delete arg from args.
 			fallthrough // (do the same as below)
-		case strings.HasPrefix(s, "-") && !strings.Contains(s, "=") && len(s) == 2 && !isShortBoolFlag(s[1:], flags):
+		case strings.HasPrefix(s, "-") && !strings.Contains(s, "=") && shortFlagsTakeValue(s[1:], flags):
 			// If '-f arg' then
 			// delete 'arg' from args or break the loop if len(args) <= 1.
 			if len(args) <= 1 {
@@ -673,6 +1321,28 @@ Loop:
 	return commands
 }
 
+// argsAfterFirstPositional returns the arguments following the first
+// positional argument of args, skipping the flags before it like stripFlags.
+func argsAfterFirstPositional(args []string, c *Command) []string {
+	c.mergePersistentFlags()
+	flags := c.Flags()
+
+	for pos := 0; pos < len(args); pos++ {
+		s := args[pos]
+		switch {
+		case s == "--":
+			return nil
+		case strings.HasPrefix(s, "--") && !strings.Contains(s, "=") && !isBoolFlag(s[2:], flags):
+			fallthrough
+		case strings.HasPrefix(s, "-") && !strings.Contains(s, "=") && shortFlagsTakeValue(s[1:], flags):
+			pos++
+		case s != "" && !strings.HasPrefix(s, "-"):
+			return args[pos+1:]
+		}
+	}
+	return nil
+}
+
 // argsMinusFirstX removes only the first x from args.  Otherwise, commands that look like
 // openshift admin policy add-role-to-user admin my-user, lose the admin argument (arg[4]).
 // Special care needs to be taken not to remove a flag value.
@@ -692,7 +1362,7 @@ Loop:
 			break Loop
 		case strings.HasPrefix(s, "--") && !strings.Contains(s, "=") && !isBoolFlag(s[2:], flags):
 			fallthrough
-		case strings.HasPrefix(s, "-") && !strings.Contains(s, "=") && len(s) == 2 && !isShortBoolFlag(s[1:], flags):
+		case strings.HasPrefix(s, "-") && !strings.Contains(s, "=") && shortFlagsTakeValue(s[1:], flags):
 			// This is a flag without a default value, and an equal sign is not used. Increment pos in order to skip
 			// over the next arg, because that is the value of this flag.
 			pos++
@@ -719,38 +1389,151 @@ func isFlagArg(arg string) bool {
 // Find the target command given the args and command tree
 // Meant to be run on the highest node. Only searches down.
 func (c *Command) Find(args []string) (*Command, []string, error) {
-	var innerfind func(*Command, []string) (*Command, []string)
+	path, a, err := c.FindPath(args)
+	return path[len(path)-1], a, err
+}
+
+// FindPath is the same as Find, but returns all the commands from the root
+// command to the target command, e.g. to build breadcrumbs.
+func (c *Command) FindPath(args []string) ([]*Command, []string, error) {
+	var innerfind func(*Command, []string, []*Command) ([]*Command, []string)
 
-	innerfind = func(c *Command, innerArgs []string) (*Command, []string) {
+	innerfind = func(c *Command, innerArgs []string, path []*Command) ([]*Command, []string) {
+		path = append(path, c)
 		argsWOflags := stripFlags(innerArgs, c)
 		if len(argsWOflags) == 0 {
-			return c, innerArgs
+			return path, innerArgs
 		}
 		nextSubCmd := argsWOflags[0]
 
 		cmd := c.findNext(nextSubCmd)
 		if cmd != nil {
-			return innerfind(cmd, c.argsMinusFirstX(innerArgs, nextSubCmd))
+			return innerfind(cmd, c.argsMinusFirstX(innerArgs, nextSubCmd), path)
 		}
-		return c, innerArgs
+		return path, innerArgs
 	}
 
-	commandFound, a := innerfind(c, args)
+	var parents []*Command
+	c.VisitParents(func(p *Command) {
+		parents = append([]*Command{p}, parents...)
+	})
+
+	path, a := innerfind(c, args, parents)
+	commandFound := path[len(path)-1]
 	if commandFound.Args == nil {
-		return commandFound, a, legacyArgs(commandFound, stripFlags(a, commandFound))
+		err := legacyArgs(commandFound, stripFlags(a, commandFound))
+		var unknownErr *unknownCommandError
+		if errors.As(err, &unknownErr) {
+			// Keep the flags following the unknown command, e.g. for an
+			// external command
+			unknownErr.args = argsAfterFirstPositional(a, commandFound)
+		}
+		return path, a, err
 	}
-	return commandFound, a, nil
+	return path, a, nil
 }
 
 func (c *Command) findSuggestions(arg string) string {
 	if c.DisableSuggestions {
 		return ""
 	}
+	return formatSuggestions(c.SuggestionsFor(arg))
+}
+
+// suggestionsMinimumDistance returns SuggestionsMinimumDistance, or its default
+// of 2 if it is not set.
+func (c *Command) suggestionsMinimumDistance() int {
 	if c.SuggestionsMinimumDistance <= 0 {
-		c.SuggestionsMinimumDistance = 2
+		return 2
+	}
+	return c.SuggestionsMinimumDistance
+}
+
+// findShorthandFlagSuggestions returns suggestions for the flag the user most
+// likely meant when err reports an unknown shorthand flag, or an empty string
+// if there are none.
+func (c *Command) findShorthandFlagSuggestions(err error) string {
+	if c.DisableSuggestions {
+		return ""
+	}
+
+	msg, ok := strings.CutPrefix(err.Error(), "unknown shorthand flag: ")
+	if !ok {
+		return ""
+	}
+	quoted, typed, ok := strings.Cut(msg, " in -")
+	if !ok {
+		return ""
 	}
+	shorthand, uerr := strconv.Unquote(quoted)
+	if uerr != nil {
+		return ""
+	}
+
+	var suggestions []string
+	c.Flags().VisitAll(func(f *zflag.Flag) {
+		if nonCompletableFlag(f) {
+			return
+		}
+
+		hasShorthand := f.Shorthand > 0 && f.ShorthandDeprecated == ""
+		switch {
+		case hasShorthand && calculateLevenshteinDistance(shorthand, string(f.Shorthand), true) == 0:
+			// Same shorthand in a different case, e.g. -V instead of -v.
+		case !f.ShorthandOnly && len(typed) > 1 &&
+			calculateLevenshteinDistance(typed, f.Name, true) <= c.suggestionsMinimumDistance():
+			// A long flag name typed with a single dash, e.g. -verbose.
+		default:
+			return
+		}
+
+		switch {
+		case hasShorthand && f.ShorthandOnly:
+			suggestions = append(suggestions, fmt.Sprintf("-%c", f.Shorthand))
+		case hasShorthand:
+			suggestions = append(suggestions, fmt.Sprintf("-%c (--%s)", f.Shorthand, f.Name))
+		default:
+			suggestions = append(suggestions, "--"+f.Name)
+		}
+	})
+
+	return formatSuggestions(suggestions)
+}
+
+// findLongFlagSuggestions returns suggestions for the flag the user most
+// likely meant when err reports an unknown flag, or an empty string if there
+// are none.
+func (c *Command) findLongFlagSuggestions(err error) string {
+	if c.DisableSuggestions {
+		return ""
+	}
+
+	msg, ok := strings.CutPrefix(err.Error(), "unknown flag: ")
+	if !ok {
+		return ""
+	}
+	typed := strings.TrimLeft(msg, "-")
+
+	var suggestions []string
+	c.Flags().VisitAll(func(f *zflag.Flag) {
+		if nonCompletableFlag(f) || f.ShorthandOnly {
+			return
+		}
+
+		suggestByLevenshtein := calculateLevenshteinDistance(typed, f.Name, true) <= c.suggestionsMinimumDistance()
+		suggestByPrefix := strings.HasPrefix(strings.ToLower(f.Name), strings.ToLower(typed))
+		if suggestByLevenshtein || suggestByPrefix {
+			suggestions = append(suggestions, "--"+f.Name)
+		}
+	})
+
+	return formatSuggestions(suggestions)
+}
+
+// formatSuggestions formats the suggestions to be appended to an error message.
+func formatSuggestions(suggestions []string) string {
 	suggestionsString := ""
-	if suggestions := c.SuggestionsFor(arg); len(suggestions) > 0 {
+	if len(suggestions) > 0 {
 		suggestionsString += "\n\nDid you mean this?\n"
 		for _, s := range suggestions {
 			suggestionsString += fmt.Sprintf("\t%v\n", s)
@@ -762,11 +1545,11 @@ func (c *Command) findSuggestions(arg string) string {
 func (c *Command) findNext(next string) *Command {
 	matches := make([]*Command, 0)
 	for _, cmd := range c.commands {
-		if cmd.Name() == next || cmd.HasAlias(next) {
+		if commandNameMatches(cmd.Name(), next) || cmd.HasAlias(next) {
 			cmd.commandCalledAs.name = next
 			return cmd
 		}
-		if EnablePrefixMatching && cmd.hasNameOrAliasPrefix(next) {
+		if c.prefixMatching() && cmd.hasNameOrAliasPrefix(next) {
 			matches = append(matches, cmd)
 		}
 	}
@@ -778,6 +1561,18 @@ func (c *Command) findNext(next string) *Command {
 	return nil
 }
 
+// prefixMatching reports whether the subcommands of the command can be invoked
+// by a prefix of their name or aliases, as set by the PrefixMatching field of
+// the command or its closest parent setting it, or else by EnablePrefixMatching.
+func (c *Command) prefixMatching() bool {
+	for p := c; p != nil; p = p.Parent() {
+		if p.PrefixMatching != nil {
+			return *p.PrefixMatching
+		}
+	}
+	return EnablePrefixMatching
+}
+
 // Traverse the command tree to find the command, and parse args for
 // each parent.
 func (c *Command) Traverse(args []string) (*Command, []string, error) {
@@ -794,7 +1589,7 @@ func (c *Command) Traverse(args []string) (*Command, []string, error) {
 			continue
 		// A short flag with a space separated value
 		case strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") &&
-			len(arg) == 2 && !isShortBoolFlag(arg[1:], c.Flags()):
+			shortFlagsTakeValue(arg[1:], c.Flags()):
 			inFlag = true
 			flags = append(flags, arg)
 			continue
@@ -822,23 +1617,102 @@ func (c *Command) Traverse(args []string) (*Command, []string, error) {
 	return c, args, nil
 }
 
+// SetSuggestionFunc sets the function used to suggest the commands the user
+// most likely meant when typing an unknown command, for the command and its
+// children. It is given the name typed and the names of the available
+// subcommands, and returns the suggestions, best first. Passing nil restores
+// the default, based on the Levenshtein distance and SuggestionsMinimumDistance.
+func (c *Command) SetSuggestionFunc(f SuggestionFn) {
+	c.suggestionFunc = f
+}
+
+// SetUnknownCommandFunc sets the function handling the unknown subcommands of
+// the command and its children, e.g. to dispatch them to external plugins. It
+// is called instead of reporting the unknown command error, and its error, if
+// any, is returned by ExecuteC as is; nil means the command was handled.
+func (c *Command) SetUnknownCommandFunc(f UnknownCommandFn) {
+	c.unknownCommandFunc = f
+}
+
+// handleUnknownCommand runs the external command or calls the unknown command
+// func of the command or of its closest parent having one when err reports an
+// unknown command, with the arguments following the unknown command. It
+// reports whether the error was handled, with the error returned by the
+// handler.
+func (c *Command) handleUnknownCommand(err error) (bool, error) {
+	var unknownErr *unknownCommandError
+	if !errors.As(err, &unknownErr) {
+		return false, nil
+	}
+	name, args := unknownErr.name, unknownErr.args
+	if path, ok := c.lookupExternalCommand(name); ok {
+		return true, c.runExternalCommand(path, args)
+	}
+	for p := c; p != nil; p = p.Parent() {
+		if p.unknownCommandFunc != nil {
+			return true, p.unknownCommandFunc(c, name, args)
+		}
+	}
+	return false, nil
+}
+
 // SuggestionsFor provides suggestions for the typedName.
 func (c *Command) SuggestionsFor(typedName string) []string {
-	var suggestions []string
-	for _, cmd := range c.commands {
-		if cmd.IsAvailableCommand() {
-			levenshteinDistance := calculateLevenshteinDistance(typedName, cmd.Name(), true)
-			suggestByLevenshtein := levenshteinDistance <= c.SuggestionsMinimumDistance
-			suggestByPrefix := strings.HasPrefix(strings.ToLower(cmd.Name()), strings.ToLower(typedName))
-			if suggestByLevenshtein || suggestByPrefix {
-				suggestions = append(suggestions, cmd.Name())
-			}
-			for _, explicitSuggestion := range cmd.SuggestFor {
-				if strings.EqualFold(typedName, explicitSuggestion) {
-					suggestions = append(suggestions, cmd.Name())
-				}
+	for p := c; p != nil; p = p.Parent() {
+		if p.suggestionFunc == nil {
+			continue
+		}
+
+		var candidates []string
+		for _, cmd := range c.commands {
+			if cmd.IsAvailableCommand() {
+				candidates = append(candidates, cmd.Name())
 			}
 		}
+		suggestions := p.suggestionFunc(typedName, candidates)
+		if c.MaxSuggestions > 0 && len(suggestions) > c.MaxSuggestions {
+			suggestions = suggestions[:c.MaxSuggestions]
+		}
+		return suggestions
+	}
+
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	var found []suggestion
+	for _, cmd := range c.commands {
+		if !cmd.IsAvailableCommand() {
+			continue
+		}
+
+		levenshteinDistance := calculateLevenshteinDistance(typedName, cmd.Name(), true)
+		suggestByLevenshtein := levenshteinDistance <= c.suggestionsMinimumDistance()
+		suggestByPrefix := strings.HasPrefix(strings.ToLower(cmd.Name()), strings.ToLower(typedName))
+		suggestExplicitly := slices.ContainsFunc(cmd.SuggestFor, func(explicitSuggestion string) bool {
+			return strings.EqualFold(typedName, explicitSuggestion)
+		})
+		if suggestExplicitly {
+			// Explicit suggestions are the closest possible match.
+			levenshteinDistance = 0
+		}
+
+		if suggestByLevenshtein || suggestByPrefix || suggestExplicitly {
+			found = append(found, suggestion{name: cmd.Name(), distance: levenshteinDistance})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].distance < found[j].distance
+	})
+	if c.MaxSuggestions > 0 && len(found) > c.MaxSuggestions {
+		found = found[:c.MaxSuggestions]
+	}
+
+	var suggestions []string
+	for _, s := range found {
+		suggestions = append(suggestions, s.name)
 	}
 	return suggestions
 }
@@ -851,6 +1725,31 @@ func (c *Command) VisitParents(fn func(*Command)) {
 	}
 }
 
+// VisitCommands invokes fn on the command, then on each of its subcommands
+// recursively, in depth-first order. The subcommands are visited sorted by
+// name, regardless of EnableCommandSorting.
+func (c *Command) VisitCommands(fn func(*Command)) {
+	fn(c)
+	for _, cmd := range c.commandsByName() {
+		cmd.VisitCommands(fn)
+	}
+}
+
+// Walk invokes fn on the command, then on each of its subcommands recursively,
+// in the same order as VisitCommands. It stops at the first error returned by
+// fn, and returns it.
+func (c *Command) Walk(fn func(*Command) error) error {
+	if err := fn(c); err != nil {
+		return err
+	}
+	for _, cmd := range c.commandsByName() {
+		if err := cmd.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Root finds root command.
 func (c *Command) Root() *Command {
 	if c.HasParent() {
@@ -871,6 +1770,14 @@ func (c *Command) CancelRun() {
 	c.RunE = nil
 }
 
+// CancelRunWithError prevents the command from running, like CancelRun, but
+// makes the execution return err instead. When called from PreRunE-style
+// functions, the RunE, PostRunE and PersistentPostRunE functions are skipped,
+// while the FinalizeE-style ones still run.
+func (c *Command) CancelRunWithError(err error) {
+	c.cancelRunErr = err
+}
+
 //nolint:gocognit,funlen // to be broken down later
 func (c *Command) execute(a []string) (err error) {
 	if c == nil {
@@ -881,10 +1788,18 @@ func (c *Command) execute(a []string) (err error) {
 		c.Printf("Command %q is deprecated, %s\n", c.Name(), c.Deprecated)
 	}
 
+	c.cancelRunErr = nil
+
 	var argWoFlags []string
 
-	// Allocate the hooks execution chain for the current command
+	// Allocate the hooks execution chain for the current command, and the
+	// phases it is made of
 	var hooks []HookFuncE
+	var phases []executionPhase
+	endPhase := func(name string) {
+		phases = append(phases, executionPhase{name: name, end: len(hooks)})
+	}
+	observer := c.executionObserver()
 
 	defer func() {
 		var finalizeHooks []HookFuncE
@@ -893,17 +1808,25 @@ func (c *Command) execute(a []string) (err error) {
 			appendHooks(&finalizeHooks, p.PersistentFinalizeE, p.persistentFinalizeHooks)
 		}
 
+		var started time.Time
+		if observer != nil {
+			started = time.Now()
+		}
 		for _, x := range finalizeHooks {
-			if err = x(c, argWoFlags); err != nil {
-				panic(err)
+			if finalizeErr := x(c, argWoFlags); finalizeErr != nil {
+				panic(finalizeErr)
 			}
 		}
+		if observer != nil {
+			observer(c, ExecutionPhaseFinalize, time.Since(started))
+		}
 	}()
 
 	for p := c; p != nil; p = p.Parent() {
 		prependHooks(&hooks, p.persistentInitializeHooks, p.PersistentInitializeE)
 	}
 	prependHooks(&hooks, c.initializeHooks, c.InitializeE)
+	endPhase(ExecutionPhaseInitialize)
 
 	// initialize help and version flag at the last point possible to allow for user
 	// overriding
@@ -917,13 +1840,31 @@ func (c *Command) execute(a []string) (err error) {
 	hooks = append(hooks, func(cmd *Command, args []string) error {
 		err = c.ParseFlags(a)
 		if err != nil {
+			if suggestions := c.findShorthandFlagSuggestions(err); suggestions != "" {
+				err = fmt.Errorf("%w%s", err, suggestions)
+			} else if suggestions := c.findLongFlagSuggestions(err); suggestions != "" {
+				err = fmt.Errorf("%w%s", err, suggestions)
+			}
 			return c.FlagErrorFunc()(c, err)
 		}
 
+		if c.DisableFlagParsing {
+			argWoFlags = a
+		} else {
+			argWoFlags = c.Flags().Args()
+		}
 		return nil
 	})
 
+	for p := c; p != nil; p = p.Parent() {
+		hooks = append(hooks, p.persistentFlagsParsedHooks...)
+	}
+
 	hooks = append(hooks, func(cmd *Command, args []string) error {
+		if c.isMinimal() && c.Flags().Lookup("help") == nil {
+			return nil
+		}
+
 		// If help is called, regardless of other flags, return we want help.
 		// Also say we need help if the command isn't runnable.
 		helpVal, err := c.Flags().GetBool("help")
@@ -962,23 +1903,17 @@ func (c *Command) execute(a []string) (err error) {
 		return nil
 	})
 
-	hooks = append(hooks, func(cmd *Command, args []string) error {
-		if c.DisableFlagParsing {
-			argWoFlags = a
-			return nil
-		}
-
-		argWoFlags = c.Flags().Args()
-		return nil
-	})
-
 	hooks = append(hooks, func(cmd *Command, args []string) error {
 		if !c.Runnable() {
+			if c.RequireSubcommand && c.HasAvailableSubCommands() {
+				return ErrSubcommandRequired
+			}
 			return zflag.ErrHelp
 		}
 
 		return c.ValidateArgs(argWoFlags)
 	})
+	endPhase(ExecutionPhaseParseFlags)
 
 	for p := c; p != nil; p = p.Parent() {
 		prependHooks(&hooks, p.persistentPreRunHooks, p.PersistentPreRunE)
@@ -986,33 +1921,80 @@ func (c *Command) execute(a []string) (err error) {
 
 	prependHooks(&hooks, c.preRunHooks, c.PreRunE)
 
-	// Include the validateFlagGroups() logic as a hook
+	hooks = append(hooks, func(cmd *Command, args []string) error {
+		return c.cancelRunErr
+	})
+
+	// Include the ValidateFlagGroups() and validateFlagOccurrences() logic as a hook
 	// to be executed before running the main Run hooks.
 	hooks = append(hooks, func(cmd *Command, args []string) error {
-		if err := c.validateFlagGroups(); err != nil {
+		if err := c.ValidateFlagGroups(); err != nil {
+			return c.FlagErrorFunc()(c, err)
+		}
+		if err := c.validateFlagOccurrences(); err != nil {
 			return c.FlagErrorFunc()(c, err)
 		}
 
 		return nil
 	})
 
-	prependHooks(&hooks, c.runHooks, c.RunE)
+	var runHooks []HookFuncE
+	prependHooks(&runHooks, c.runHooks, c.RunE)
+	if c.wrapsRunErrors() {
+		for i, hook := range runHooks {
+			runHooks[i] = func(cmd *Command, args []string) error {
+				if err := hook(cmd, args); err != nil {
+					return fmt.Errorf("%s: %w", cmd.CommandPath(), err)
+				}
+				return nil
+			}
+		}
+	}
+	endPhase(ExecutionPhasePreRun)
+	hooks = append(hooks, runHooks...)
+	endPhase(ExecutionPhaseRun)
 	prependHooks(&hooks, c.postRunHooks, c.PostRunE)
 
 	for p := c; p != nil; p = p.Parent() {
 		appendHooks(&hooks, p.PersistentPostRunE, p.persistentPostRunHooks)
 	}
+	endPhase(ExecutionPhasePostRun)
 
-	// Execute the hooks execution chain:
-	for _, x := range hooks {
-		if err := x(c, argWoFlags); err != nil {
+	// Execute the hooks execution chain phase by phase, stopping as soon as the context is cancelled:
+	start := 0
+	for _, phase := range phases {
+		var started time.Time
+		if observer != nil {
+			started = time.Now()
+		}
+		err := c.runHookChain(hooks[start:phase.end], &argWoFlags)
+		if observer != nil {
+			observer(c, phase.name, time.Since(started))
+		}
+		if err != nil {
 			return err
 		}
+		start = phase.end
 	}
 
 	return nil
 }
 
+// runHookChain runs hooks in order, stopping at the first error or as soon as
+// the context is cancelled. The args are read at each call, as the hooks
+// parsing the flags fill them in.
+func (c *Command) runHookChain(hooks []HookFuncE, args *[]string) error {
+	for _, x := range hooks {
+		if err := c.ctx.Err(); err != nil {
+			return fmt.Errorf("%s aborted: %w", c.CommandPath(), context.Cause(c.ctx))
+		}
+		if err := x(c, *args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func prependHooks(hooks *[]HookFuncE, newHooks []HookFuncE, runE HookFuncE) {
 	*hooks = append(*hooks, newHooks...)
 	if runE != nil {
@@ -1033,6 +2015,14 @@ func (c *Command) OnPersistentInitialize(f ...HookFuncE) {
 	c.persistentInitializeHooks = append(c.persistentInitializeHooks, f...)
 }
 
+// OnPersistentFlagsParsed registers one or more hooks on the command to be executed
+// once the flags of the command or one of its children are parsed and set from the
+// environment, the flag sources and the config file, before the help and version
+// flags are handled and before any PreRunE-style function, e.g. to apply a --verbose flag.
+func (c *Command) OnPersistentFlagsParsed(f ...HookFuncE) {
+	c.persistentFlagsParsedHooks = append(c.persistentFlagsParsedHooks, f...)
+}
+
 // OnInitialize registers one or more hooks on the command to be executed
 // before the flags of the command are parsed.
 func (c *Command) OnInitialize(f ...HookFuncE) {
@@ -1094,6 +2084,39 @@ func (c *Command) Execute() error {
 	return err
 }
 
+// ExecuteContextCancelOnSignal is the same as ExecuteContext(), with a context
+// cancelled when one of the signals is received, os.Interrupt and SIGTERM if
+// none are given. The signals are handled as usual again once it returns.
+func (c *Command) ExecuteContextCancelOnSignal(signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	parent := c.Context()
+	defer c.SetContext(parent)
+
+	ctx, stop := signal.NotifyContext(parent, signals...)
+	defer stop()
+	return c.ExecuteContext(ctx)
+}
+
+// ExecuteWithExitCode is the same as Execute(), but returns the exit code of
+// the program instead of the error: 0 on success, the code of the first
+// ExitCodeError in the error chain if there is one, and 1 otherwise. The error
+// has already been printed by then, unless SilenceErrors is set.
+func (c *Command) ExecuteWithExitCode() int {
+	_, err := c.ExecuteC()
+	if err == nil {
+		return 0
+	}
+
+	var exitErr ExitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
 // ExecuteContextC is the same as ExecuteC(), but sets the ctx on the command.
 // Retrieve ctx by calling cmd.Context() inside your *RunE lifecycle or ValidArgs
 // functions.
@@ -1102,6 +2125,36 @@ func (c *Command) ExecuteContextC(ctx context.Context) (*Command, error) {
 	return c.ExecuteC()
 }
 
+// trailingHelpWordToFlag replaces the trailing "help" word of args, the
+// arguments of cmd, by the help flag. It leaves args as is when cmd is the
+// help command or a completion request, when cmd does not parse its flags, or
+// when the word is a positional argument following "--" or the value of the
+// flag before it.
+func trailingHelpWordToFlag(cmd *Command, args []string) []string {
+	if len(args) == 0 || args[len(args)-1] != "help" || slices.Contains(args, "--") {
+		return args
+	}
+	if cmd == cmd.Root().helpCommand || isShellCompRequestCmd(cmd) || cmd.DisableFlagParsing {
+		return args
+	}
+
+	if len(args) > 1 {
+		cmd.mergePersistentFlags()
+		prev := args[len(args)-2]
+		switch {
+		case strings.HasPrefix(prev, "--") && !strings.Contains(prev, "="):
+			if !isBoolFlag(prev[2:], cmd.Flags()) {
+				return args
+			}
+		case strings.HasPrefix(prev, "-") && !strings.Contains(prev, "="):
+			if shortFlagsTakeValue(prev[1:], cmd.Flags()) {
+				return args
+			}
+		}
+	}
+	return append(slices.Clone(args[:len(args)-1]), "--help")
+}
+
 // ExecuteC executes the command.
 //
 //nolint:gocognit // todo later
@@ -1110,6 +2163,14 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 		c.ctx = context.Background()
 	}
 
+	if err := c.resetFlagsErr; err != nil {
+		c.resetFlagsErr = nil
+		if !c.SilenceErrors {
+			c.PrintErrln(c.ErrPrefix(), err.Error())
+		}
+		return c, err
+	}
+
 	// Regardless of what command execute is called on, run on Root only
 	if c.HasParent() {
 		return c.Root().ExecuteC()
@@ -1144,25 +2205,38 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 		if cmd != nil {
 			c = cmd
 		}
+		if handled, err := c.handleUnknownCommand(err); handled {
+			return c, err
+		}
 		if !c.SilenceErrors {
-			c.PrintErrln("Error:", err.Error())
+			c.PrintErrln(c.ErrPrefix(), err.Error())
 			c.PrintErrf("%s", cmd.UsageHintString())
 		}
 		return c, err
 	}
 
+	if c.EnableTrailingHelpWord {
+		flags = trailingHelpWordToFlag(cmd, flags)
+	}
+
 	cmd.commandCalledAs.called = true
 	if cmd.commandCalledAs.name == "" {
 		cmd.commandCalledAs.name = cmd.Name()
 	}
 
-	cmd.ctx = c.ctx
+	cmd.ctx = withCommand(c.ctx, cmd)
 
 	err = cmd.execute(flags)
+	if handled, handlerErr := cmd.handleUnknownCommand(err); handled {
+		return cmd, handlerErr
+	}
 	if err != nil { //nolint:nestif // todo refactor later
 		// Exit without errors when version requested. At this point the
 		// version has already been printed.
 		if errors.Is(err, ErrVersion) {
+			if c.PropagateSentinelErrors {
+				return cmd, err
+			}
 			return cmd, nil
 		}
 
@@ -1170,13 +2244,23 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 		// effect
 		if errors.Is(err, zflag.ErrHelp) {
 			cmd.HelpFunc()(cmd, args)
+			if c.ReturnErrOnHelp || c.PropagateSentinelErrors {
+				return cmd, ErrHelpRequested
+			}
 			return cmd, nil
 		}
 
+		// The command asked for its help to be shown, which takes the
+		// place of the error and usage messages.
+		if errors.Is(err, ErrShowHelp) {
+			cmd.HelpFunc()(cmd, args)
+			return cmd, err
+		}
+
 		// If root command has SilenceErrors flagged,
 		// all subcommands should respect it
 		if !cmd.SilenceErrors && !c.SilenceErrors {
-			c.PrintErrln("Error:", err.Error())
+			c.PrintErrln(cmd.ErrPrefix(), err.Error())
 		}
 
 		// If root command has SilenceUsage flagged,
@@ -1192,11 +2276,13 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 }
 
 // ValidateArgs returns an error if any positional args are not in the
-// `ValidArgs` field of `Command`. Then, run the `Args` validator, if
-// specified.
+// `ValidArgs` field of `Command`, unless the `Args` validator is OnlyValidArgs
+// or ExactValidArgs. Then, run the `Args` validator, if specified.
 func (c *Command) ValidateArgs(args []string) error {
-	if err := validateArgs(c, args); err != nil {
-		return err
+	if !checksValidArgs(c.Args) {
+		if err := validateArgs(c, args); err != nil {
+			return err
+		}
 	}
 
 	if c.Args == nil {
@@ -1211,6 +2297,9 @@ func (c *Command) ValidateArgs(args []string) error {
 // If c already has help flag, it will do nothing.
 func (c *Command) InitDefaultHelpFlag() {
 	c.mergePersistentFlags()
+	if c.isMinimal() {
+		return
+	}
 	if c.Flags().Lookup("help") == nil {
 		usage := "help for "
 		if c.Name() == "" {
@@ -1258,11 +2347,11 @@ func (c *Command) InitDefaultVersionFlag() {
 
 // InitDefaultHelpCmd adds default help command to c.
 // It is called automatically by executing the c or by calling help and usage.
-// If c already has help command or c has no subcommands, it will do nothing.
+// If c already has help command, c has no subcommands or c is minimal, it will do nothing.
 //
 //nolint:gocognit // todo later
 func (c *Command) InitDefaultHelpCmd() {
-	if !c.HasSubCommands() {
+	if !c.HasSubCommands() || c.isMinimal() {
 		return
 	}
 
@@ -1283,9 +2372,16 @@ Simply type ` + c.Name() + ` help [path to command] for full details.`,
 					// Root help command.
 					cmd = c.Root()
 				}
+				filter := cmd.completionCommandFilter()
 				for _, subCmd := range cmd.Commands() {
+					if isShellCompRequestCmd(subCmd) {
+						continue
+					}
+					if filter != nil && !filter(subCmd, args, toComplete) {
+						continue
+					}
 					if subCmd.IsAvailableCommand() || subCmd == cmd.helpCommand {
-						if strings.HasPrefix(subCmd.Name(), toComplete) {
+						if commandNameHasPrefix(subCmd.Name(), toComplete) {
 							completions = append(completions, fmt.Sprintf("%s\t%s", subCmd.Name(), subCmd.Short))
 						}
 					}
@@ -1304,7 +2400,8 @@ Simply type ` + c.Name() + ` help [path to command] for full details.`,
 
 				return nil
 			},
-			Group: c.helpCommandGroup,
+			Group:       c.helpCommandGroup,
+			Annotations: map[string]string{CommandSetByZuluAnnotation: "true"},
 		}
 	}
 	c.RemoveCommand(c.helpCommand)
@@ -1315,6 +2412,7 @@ Simply type ` + c.Name() + ` help [path to command] for full details.`,
 func (c *Command) ResetCommands() {
 	c.parent = nil
 	c.commands = nil
+	c.commandsAreSorted = false
 	c.helpCommand = nil
 	c.parentsPflags = nil
 }
@@ -1328,12 +2426,21 @@ func (c commandSorterByName) Less(i, j int) bool { return c[i].Name() < c[j].Nam
 
 // Commands returns a sorted slice of child commands.
 func (c *Command) Commands() []*Command {
-	// do not sort commands if it already sorted or sorting was disabled
-	if EnableCommandSorting && !c.commandsAreSorted {
-		sort.Sort(commandSorterByName(c.commands))
+	if !EnableCommandSorting {
+		return c.commands
+	}
+	return c.commandsByName()
+}
+
+// commandsByName returns the child commands sorted by name.
+func (c *Command) commandsByName() []*Command {
+	// do not sort commands if they are already sorted
+	if !c.commandsAreSorted {
+		c.sortedCommands = slices.Clone(c.commands)
+		sort.Sort(commandSorterByName(c.sortedCommands))
 		c.commandsAreSorted = true
 	}
-	return c.commands
+	return c.sortedCommands
 }
 
 // AddCommand adds one or more commands to this parent command.
@@ -1343,6 +2450,9 @@ func (c *Command) AddCommand(cmds ...*Command) {
 			panic("Command can't be a child of itself")
 		}
 		cmds[i].parent = c
+		if x.Group == "" {
+			x.Group = c.DefaultCommandGroup
+		}
 		// if Group is not defined generate a new one with same title
 		if x.Group != "" && !c.ContainsGroup(x.Group) {
 			c.AddGroup(Group{Group: x.Group, Title: x.Group})
@@ -1362,6 +2472,46 @@ func (c *Command) Groups() []Group {
 	return c.commandGroups
 }
 
+// GroupsWithCommands returns the groups of the children commands along with the
+// commands in them, in the order they are rendered in the usage: first the
+// ungrouped commands in a group with an empty ID and title, then the groups in
+// the order they were added. The hidden and deprecated commands are left out,
+// as are the groups without commands, and the commands are sorted unless
+// EnableCommandSorting is false. Use GroupTitle to get the title to render.
+func (c *Command) GroupsWithCommands() []GroupWithCommands {
+	groups := make([]GroupWithCommands, 0, len(c.commandGroups)+1)
+	for _, group := range append([]Group{{}}, c.commandGroups...) {
+		var cmds []*Command
+		for _, cmd := range c.Commands() {
+			if cmd.Group == group.Group && (cmd.IsAvailableCommand() || cmd == c.helpCommand) {
+				cmds = append(cmds, cmd)
+			}
+		}
+		if len(cmds) > 0 {
+			groups = append(groups, GroupWithCommands{Group: group, Commands: cmds})
+		}
+	}
+	return groups
+}
+
+// SetGroupTitleFunc sets the function computing the titles of the command
+// groups when rendering the usage, e.g. to localize them. It is given the
+// group ID, and is inherited by the children commands.
+func (c *Command) SetGroupTitleFunc(f func(groupID string) string) {
+	c.groupTitleFunc = f
+}
+
+// GroupTitle returns the title of the group to render in the usage, as
+// computed by the group title func if set, or the registered title otherwise.
+func (c *Command) GroupTitle(group Group) string {
+	for p := c; p != nil; p = p.Parent() {
+		if p.groupTitleFunc != nil {
+			return p.groupTitleFunc(group.Group)
+		}
+	}
+	return group.Title
+}
+
 // ContainsGroup return if group is in command groups.
 func (c *Command) ContainsGroup(group string) bool {
 	for _, x := range c.commandGroups {
@@ -1372,6 +2522,21 @@ func (c *Command) ContainsGroup(group string) bool {
 	return false
 }
 
+// AllChildCommandsHaveGroup returns an error listing the children commands
+// shown in the usage that have no group, or nil if they all have one.
+func (c *Command) AllChildCommandsHaveGroup() error {
+	var ungrouped []string
+	for _, cmd := range c.commands {
+		if cmd.Group == "" && (cmd.IsAvailableCommand() || cmd == c.helpCommand) {
+			ungrouped = append(ungrouped, cmd.Name())
+		}
+	}
+	if len(ungrouped) > 0 {
+		return fmt.Errorf("commands %v of %q have no group", ungrouped, c.CommandPath())
+	}
+	return nil
+}
+
 // AddGroup adds one or more command groups to this parent command.
 func (c *Command) AddGroup(groups ...Group) {
 	c.commandGroups = append(c.commandGroups, groups...)
@@ -1391,6 +2556,7 @@ main:
 		commands = append(commands, command)
 	}
 	c.commands = commands
+	c.commandsAreSorted = false
 }
 
 // Print is a convenience method to Print to the defined output, fallback to Stderr if not set.
@@ -1431,13 +2597,37 @@ func (c *Command) CommandPath() string {
 	return c.Name()
 }
 
+// AddUsageForm adds a usage form to the command, for commands which accept
+// several forms of arguments, e.g. "add [-F file | -D dir]... profile". A form
+// is written like Use and the forms replace Use in the usage lines.
+func (c *Command) AddUsageForm(form string) {
+	c.usageForms = append(c.usageForms, form)
+}
+
+// UseLines puts out the full usage lines for a given command (including parents),
+// one per usage form added with AddUsageForm, or the UseLine if there are none.
+func (c *Command) UseLines() []string {
+	if len(c.usageForms) == 0 {
+		return []string{c.UseLine()}
+	}
+	lines := make([]string, 0, len(c.usageForms))
+	for _, form := range c.usageForms {
+		lines = append(lines, c.useLine(form))
+	}
+	return lines
+}
+
 // UseLine puts out the full usage for a given command (including parents).
 func (c *Command) UseLine() string {
+	return c.useLine(c.Use)
+}
+
+func (c *Command) useLine(use string) string {
 	var useline string
 	if c.HasParent() {
-		useline = c.parent.CommandPath() + " " + c.Use
+		useline = c.parent.CommandPath() + " " + use
 	} else {
-		useline = c.Use
+		useline = use
 	}
 	if c.DisableFlagsInUseLine {
 		return useline
@@ -1504,7 +2694,7 @@ func (c *Command) Name() string {
 // HasAlias determines if a given string is an alias of the command.
 func (c *Command) HasAlias(s string) bool {
 	for _, a := range c.Aliases {
-		if a == s {
+		if commandNameMatches(a, s) {
 			return true
 		}
 	}
@@ -1522,12 +2712,12 @@ func (c *Command) CalledAs() string {
 
 // with prefix.
 func (c *Command) hasNameOrAliasPrefix(prefix string) bool {
-	if strings.HasPrefix(c.Name(), prefix) {
+	if commandNameHasPrefix(c.Name(), prefix) {
 		c.commandCalledAs.name = c.Name()
 		return true
 	}
 	for _, alias := range c.Aliases {
-		if strings.HasPrefix(alias, prefix) {
+		if commandNameHasPrefix(alias, prefix) {
 			c.commandCalledAs.name = alias
 			return true
 		}
@@ -1542,7 +2732,26 @@ func (c *Command) NameAndAliases() string {
 
 // HasExample determines if the command has example.
 func (c *Command) HasExample() bool {
-	return len(c.Example) > 0
+	return len(c.Example) > 0 || len(c.Examples) > 0
+}
+
+// ExampleText returns the examples of the command as text: the Examples, each
+// preceded by its description as a comment and separated by blank lines, or
+// the Example if there are none.
+func (c *Command) ExampleText() string {
+	if len(c.Examples) == 0 {
+		return c.Example
+	}
+
+	examples := make([]string, 0, len(c.Examples))
+	for _, example := range c.Examples {
+		if example.Description != "" {
+			examples = append(examples, "# "+example.Description+"\n"+example.Command)
+		} else {
+			examples = append(examples, example.Command)
+		}
+	}
+	return strings.Join(examples, "\n\n")
 }
 
 // Runnable determines if the command is itself runnable.
@@ -1555,6 +2764,26 @@ func (c *Command) HasSubCommands() bool {
 	return len(c.commands) > 0
 }
 
+// wrapsRunErrors returns true if c or one of its parents sets WrapRunErrors.
+func (c *Command) wrapsRunErrors() bool {
+	for p := c; p != nil; p = p.Parent() {
+		if p.WrapRunErrors {
+			return true
+		}
+	}
+	return false
+}
+
+// isMinimal returns true if c or one of its parents is Minimal.
+func (c *Command) isMinimal() bool {
+	for p := c; p != nil; p = p.Parent() {
+		if p.Minimal {
+			return true
+		}
+	}
+	return false
+}
+
 // IsAvailableCommand determines if a command is available as a non-help command
 // (this includes all non deprecated/hidden commands).
 func (c *Command) IsAvailableCommand() bool {
@@ -1641,6 +2870,7 @@ func (c *Command) GlobalNormalizationFunc() func(f *zflag.FlagSet, name string)
 func (c *Command) Flags() *zflag.FlagSet {
 	if c.flags == nil {
 		c.flags = zflag.NewFlagSet(c.Name(), zflag.ContinueOnError)
+		c.flags.SortFlags = DefaultSortFlags
 		if c.flagErrorBuf == nil {
 			c.flagErrorBuf = new(bytes.Buffer)
 		}
@@ -1697,6 +2927,7 @@ func (c *Command) InheritedFlags() *zflag.FlagSet {
 
 	if c.iflags == nil {
 		c.iflags = zflag.NewFlagSet(c.Name(), zflag.ContinueOnError)
+		c.iflags.SortFlags = DefaultSortFlags
 		if c.flagErrorBuf == nil {
 			c.flagErrorBuf = new(bytes.Buffer)
 		}
@@ -1725,6 +2956,7 @@ func (c *Command) NonInheritedFlags() *zflag.FlagSet {
 func (c *Command) PersistentFlags() *zflag.FlagSet {
 	if c.pflags == nil {
 		c.pflags = zflag.NewFlagSet(c.Name(), zflag.ContinueOnError)
+		c.pflags.SortFlags = DefaultSortFlags
 		if c.flagErrorBuf == nil {
 			c.flagErrorBuf = new(bytes.Buffer)
 		}
@@ -1738,8 +2970,10 @@ func (c *Command) ResetFlags() {
 	c.flagErrorBuf = new(bytes.Buffer)
 	c.flagErrorBuf.Reset()
 	c.flags = zflag.NewFlagSet(c.Name(), zflag.ContinueOnError)
+	c.flags.SortFlags = DefaultSortFlags
 	c.flags.SetOutput(c.flagErrorBuf)
 	c.pflags = zflag.NewFlagSet(c.Name(), zflag.ContinueOnError)
+	c.pflags.SortFlags = DefaultSortFlags
 	c.pflags.SetOutput(c.flagErrorBuf)
 
 	c.lflags = nil
@@ -1825,11 +3059,37 @@ func (c *Command) ParseFlags(args []string) error {
 	}
 	beforeErrorBufLen := c.flagErrorBuf.Len()
 	c.mergePersistentFlags()
+	if c.Root().AutoResetFlagsOnSetArgs {
+		c.Flags().VisitAll(func(flag *zflag.Flag) {
+			if !flag.Changed {
+				c.recordFlagDefault(flag)
+			}
+		})
+	}
 
-	// do it here after merging all flags and just before parse
-	c.Flags().ParseErrorsAllowList = zflag.ParseErrorsAllowList(c.FParseErrAllowList)
+	// do it here after merging all flags and just before parse, leaving the
+	// required flags to be checked once the flags bound to an environment
+	// variable or a source have been set
+	allowList := zflag.ParseErrorsAllowList(c.FParseErrAllowList)
+	c.Flags().ParseErrorsAllowList = allowList
+	c.Flags().ParseErrorsAllowList.RequiredFlags = true
 
-	err := c.Flags().Parse(args)
+	c.flagOccurrences = map[string]int{}
+	fs := c.Flags()
+	err := fs.ParseAll(args, func(flag *zflag.Flag, value string) error {
+		c.flagOccurrences[flag.Name]++
+		return fs.Set(flag.Name, value)
+	})
+	fs.ParseErrorsAllowList = allowList
+	if err == nil {
+		err = c.applyFlagEnv()
+	}
+	if err == nil {
+		err = c.applyFlagSources()
+	}
+	if err == nil {
+		err = fs.Validate()
+	}
 	// Print warnings if they occurred (e.g. deprecated flag messages).
 	if c.flagErrorBuf.Len()-beforeErrorBufLen > 0 && err == nil {
 		c.Print(c.flagErrorBuf.String())
@@ -8,8 +8,12 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2"
@@ -126,10 +130,12 @@ func TestSubcommandExecuteC(t *testing.T) {
 }
 
 func TestExecuteContext(t *testing.T) {
-	ctx := context.TODO()
+	type ctxKey struct{}
+	ctx := context.WithValue(context.TODO(), ctxKey{}, "value")
 
 	ctxRun := func(cmd *zulu.Command, args []string) error {
-		testutil.AssertEqualf(t, ctx, cmd.Context(), "Command %q must have context when called with ExecuteContext", cmd.Use)
+		testutil.AssertEqualf(t, "value", cmd.Context().Value(ctxKey{}), "Command %q must have context when called with ExecuteContext", cmd.Use)
+		testutil.AssertEqualf(t, cmd, zulu.CommandFromContext(cmd.Context()), "Command %q must be in its context", cmd.Use)
 		return nil
 	}
 
@@ -151,10 +157,12 @@ func TestExecuteContext(t *testing.T) {
 }
 
 func TestExecuteContextC(t *testing.T) {
-	ctx := context.TODO()
+	type ctxKey struct{}
+	ctx := context.WithValue(context.TODO(), ctxKey{}, "value")
 
 	ctxRun := func(cmd *zulu.Command, args []string) error {
-		testutil.AssertEqualf(t, ctx, cmd.Context(), "Command %q must have context when called with ExecuteContext", cmd.Use)
+		testutil.AssertEqualf(t, "value", cmd.Context().Value(ctxKey{}), "Command %q must have context when called with ExecuteContext", cmd.Use)
+		testutil.AssertEqualf(t, cmd, zulu.CommandFromContext(cmd.Context()), "Command %q must be in its context", cmd.Use)
 		return nil
 	}
 
@@ -175,9 +183,200 @@ func TestExecuteContextC(t *testing.T) {
 	testutil.AssertNilf(t, err, "Command child must not fail")
 }
 
+func TestExecuteContextCancelled(t *testing.T) {
+	var ran bool
+	run := func(cmd *zulu.Command, args []string) error {
+		ran = true
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rootCmd := &zulu.Command{Use: "root", RunE: run}
+	_, err := executeCommandWithContext(ctx, rootCmd)
+	testutil.AssertEqualf(t, true, errors.Is(err, context.Canceled), "Expected context.Canceled, got %v", err)
+	testutil.AssertEqualf(t, false, ran, "RunE must not be reached once the context is cancelled")
+
+	// A hook cancelling the context stops the hooks after it
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	var preRan bool
+	rootCmd = &zulu.Command{
+		Use: "root",
+		PreRunE: func(cmd *zulu.Command, args []string) error {
+			preRan = true
+			cancel()
+			return nil
+		},
+		RunE: run,
+	}
+	_, err = executeCommandWithContext(ctx, rootCmd)
+	testutil.AssertEqualf(t, true, errors.Is(err, context.Canceled), "Expected context.Canceled, got %v", err)
+	testutil.AssertEqualf(t, true, preRan, "PreRunE must run before the context is cancelled")
+	testutil.AssertEqualf(t, false, ran, "RunE must not be reached once the context is cancelled")
+}
+
+func TestExecuteContextCancelOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("os.Interrupt cannot be sent on windows")
+	}
+
+	var postRan bool
+	rootCmd := &zulu.Command{
+		Use: "root",
+		RunE: func(cmd *zulu.Command, args []string) error {
+			p, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				return err
+			}
+			if err := p.Signal(os.Interrupt); err != nil {
+				return err
+			}
+
+			select {
+			case <-cmd.Context().Done():
+				return nil
+			case <-time.After(5 * time.Second):
+				return errors.New("context not cancelled on interrupt")
+			}
+		},
+		PostRunE: func(cmd *zulu.Command, args []string) error {
+			postRan = true
+			return nil
+		},
+	}
+	rootCmd.SetArgs([]string{})
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+
+	err := rootCmd.ExecuteContextCancelOnSignal()
+	testutil.AssertEqualf(t, true, errors.Is(err, context.Canceled), "Expected context.Canceled, got %v", err)
+	testutil.AssertEqualf(t, false, postRan, "PostRunE must not be reached once the context is cancelled")
+	testutil.AssertNilf(t, rootCmd.Context().Err(), "The context must be restored")
+}
+
+func TestCancelRunWithError(t *testing.T) {
+	errCancelled := errors.New("cancelled")
+	var ran []string
+	record := func(name string) zulu.HookFuncE {
+		return func(cmd *zulu.Command, args []string) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	rootCmd := &zulu.Command{
+		Use:                 "root",
+		PersistentPostRunE:  record("persistentPostRun"),
+		PersistentFinalizeE: record("persistentFinalize"),
+	}
+	childCmd := &zulu.Command{
+		Use: "child",
+		PreRunE: func(cmd *zulu.Command, args []string) error {
+			ran = append(ran, "preRun")
+			cmd.CancelRunWithError(errCancelled)
+			return nil
+		},
+		RunE:      record("run"),
+		PostRunE:  record("postRun"),
+		FinalizeE: record("finalize"),
+	}
+	rootCmd.AddCommand(childCmd)
+
+	output, err := executeCommand(rootCmd, "child")
+	testutil.AssertEqualf(t, true, errors.Is(err, errCancelled), "Expected the cancel error, got %v", err)
+	testutil.AssertContains(t, output, "Error: cancelled")
+	testutil.AssertEqual(t, "preRun finalize persistentFinalize", strings.Join(ran, " "))
+
+	// The error does not carry over to the next execution
+	ran = nil
+	childCmd.PreRunE = record("preRun")
+	_, err = executeCommand(rootCmd, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "preRun run postRun persistentPostRun finalize persistentFinalize", strings.Join(ran, " "))
+}
+
+func TestSetErrPrefix(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	childCmd := &zulu.Command{
+		Use:  "child",
+		RunE: func(*zulu.Command, []string) error { return errors.New("failed") },
+	}
+	rootCmd.AddCommand(childCmd)
+
+	output, err := executeCommand(rootCmd, "child")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertContains(t, output, "Error: failed\n")
+
+	rootCmd.SetErrPrefix("ERREUR:")
+	testutil.AssertEqual(t, "ERREUR:", childCmd.ErrPrefix())
+
+	output, err = executeCommand(rootCmd, "child")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertContains(t, output, "ERREUR: failed\n")
+
+	output, err = executeCommand(rootCmd, "unknown")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertContains(t, output, `ERREUR: unknown command "unknown" for "root"`)
+
+	childCmd.SetErrPrefix("child error:")
+	output, err = executeCommand(rootCmd, "child")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertContains(t, output, "child error: failed\n")
+}
+
+func TestSetExecutionObserver(t *testing.T) {
+	var phases []string
+	var runDuration time.Duration
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	observer := func(cmd *zulu.Command, phase string, d time.Duration) {
+		phases = append(phases, cmd.Name()+":"+phase)
+		if phase == zulu.ExecutionPhaseRun {
+			runDuration = d
+		}
+	}
+	rootCmd.SetExecutionObserver(observer)
+
+	childCmd := &zulu.Command{
+		Use: "child",
+		PreRunE: func(cmd *zulu.Command, args []string) error {
+			if len(args) > 0 {
+				return errors.New("no args")
+			}
+			return nil
+		},
+		RunE: func(cmd *zulu.Command, args []string) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(childCmd)
+
+	_, err := executeCommand(rootCmd, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "child:Initialize child:ParseFlags child:PreRun child:Run child:PostRun child:Finalize", strings.Join(phases, " "))
+	testutil.AssertEqualf(t, true, runDuration >= 10*time.Millisecond, "Unexpected duration of the run phase: %v", runDuration)
+
+	phases = nil
+	rootCmd.SetExecutionObserver(nil)
+	_, err = executeCommand(rootCmd, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, 0, len(phases))
+	rootCmd.SetExecutionObserver(observer)
+
+	// The phases after the failing one are not reported, except the finalize one
+	phases = nil
+	_, err = executeCommand(rootCmd, "child", "arg")
+	testutil.AssertNotNilf(t, err, "Expected error")
+	testutil.AssertEqual(t, "child:Initialize child:ParseFlags child:PreRun child:Finalize", strings.Join(phases, " "))
+}
+
 func TestExecute_NoContext(t *testing.T) {
 	run := func(cmd *zulu.Command, args []string) error {
-		testutil.AssertEqualf(t, context.Background(), cmd.Context(), "Command %s must have background context", cmd.Use)
+		testutil.AssertEqualf(t, true, cmd.Context().Done() == nil, "Command %s must have background context", cmd.Use)
+		testutil.AssertEqualf(t, cmd, zulu.CommandFromContext(cmd.Context()), "Command %q must be in its context", cmd.Use)
 		return nil
 	}
 
@@ -252,6 +451,78 @@ func TestEnablePrefixMatching(t *testing.T) {
 	zulu.EnablePrefixMatching = false
 }
 
+func TestEnableCaseInsensitive(t *testing.T) {
+	var calledAs string
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	rootCmd.AddCommand(&zulu.Command{
+		Use:     "myCmd",
+		Aliases: []string{"myAlias"},
+		Args:    zulu.NoArgs,
+		RunE: func(cmd *zulu.Command, _ []string) error {
+			calledAs = cmd.CalledAs()
+			return nil
+		},
+	})
+
+	_, err := executeCommand(rootCmd, "mycmd")
+	testutil.AssertNotNilf(t, err, "Expected an error when case insensitivity is disabled")
+
+	zulu.EnableCaseInsensitive = true
+	defer func() { zulu.EnableCaseInsensitive = false }()
+
+	for _, name := range []string{"myCmd", "mycmd", "MYCMD", "MyAlias"} {
+		calledAs = ""
+		_, err = executeCommand(rootCmd, name)
+		testutil.AssertNilf(t, err, "Unexpected error for %q: %v", name, err)
+		testutil.AssertEqual(t, name, calledAs)
+	}
+
+	// Completions use the canonical name
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "MY")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, strings.Join([]string{
+		"myCmd",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"), output)
+
+	zulu.EnablePrefixMatching = true
+	defer func() { zulu.EnablePrefixMatching = false }()
+	calledAs = ""
+	_, err = executeCommand(rootCmd, "MYA")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "myAlias", calledAs)
+}
+
+func TestPrefixMatchingPerCommand(t *testing.T) {
+	enabled, disabled := true, false
+
+	// Two command trees with different settings
+	enabledRoot := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun, PrefixMatching: &enabled}
+	enabledChild := &zulu.Command{Use: "child", RunE: noopRun}
+	enabledChild.AddCommand(&zulu.Command{Use: "grandchild", Args: zulu.NoArgs, RunE: noopRun})
+	enabledRoot.AddCommand(enabledChild)
+
+	disabledRoot := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun, PrefixMatching: &disabled}
+	disabledRoot.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	_, err := executeCommand(enabledRoot, "ch", "grand")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	_, err = executeCommand(disabledRoot, "ch")
+	testutil.AssertNotNilf(t, err, "Expected an error when prefix matching is disabled")
+
+	// The global setting is used when the field is nil, but not when it is set
+	zulu.EnablePrefixMatching = true
+	defer func() { zulu.EnablePrefixMatching = false }()
+
+	defaultRoot := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	defaultRoot.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	_, err = executeCommand(defaultRoot, "ch")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	_, err = executeCommand(disabledRoot, "ch")
+	testutil.AssertNotNilf(t, err, "Expected an error when prefix matching is disabled")
+}
+
 func TestAliasPrefixMatching(t *testing.T) {
 	zulu.EnablePrefixMatching = true
 
@@ -477,6 +748,14 @@ func TestStripFlags(t *testing.T) {
 			[]string{"-p", "bar"},
 			[]string{"bar"},
 		},
+		{
+			[]string{"-bs", "foo", "bar"},
+			[]string{"bar"},
+		},
+		{
+			[]string{"-bsfoo", "bar"},
+			[]string{"bar"},
+		},
 	}
 
 	c := &zulu.Command{Use: "c", RunE: noopRun}
@@ -563,6 +842,136 @@ func TestChildFlagShadowsParentPersistentFlag(t *testing.T) {
 	testutil.AssertNotNilf(t, childLocal.Lookup("intf"), `LocalFlags expected to contain "intf"`)
 }
 
+func TestInheritedFlagsWithoutZuluFlags(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Version: "1.0.0", RunE: noopRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	rootCmd.AddCommand(childCmd)
+
+	_, err := executeCommand(rootCmd)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	_, err = executeCommand(rootCmd, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	// The help and version flags added by zulu are local to each command
+	testutil.AssertNotNilf(t, rootCmd.LocalFlags().Lookup("version"), "Expected a version flag on root")
+	var inherited []string
+	childCmd.InheritedFlags().VisitAll(func(f *zflag.Flag) { inherited = append(inherited, f.Name) })
+	testutil.AssertEqual(t, "verbose", strings.Join(inherited, " "))
+}
+
+func TestResolveAnnotation(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:                    "root",
+		RunE:                   noopRun,
+		InheritableAnnotations: map[string]string{"category": "root", "team": "core"},
+	}
+	childCmd := &zulu.Command{
+		Use:                    "child",
+		RunE:                   noopRun,
+		Annotations:            map[string]string{"team": "not inherited"},
+		InheritableAnnotations: map[string]string{"category": "child"},
+	}
+	grandchildCmd := &zulu.Command{Use: "grandchild", RunE: noopRun}
+	// The annotations resolve whatever the order the commands are added in
+	childCmd.AddCommand(grandchildCmd)
+	rootCmd.AddCommand(childCmd)
+
+	testCases := []struct {
+		cmd      *zulu.Command
+		key      string
+		expected string
+		found    bool
+	}{
+		{cmd: rootCmd, key: "category", expected: "root", found: true},
+		{cmd: childCmd, key: "category", expected: "child", found: true},
+		{cmd: grandchildCmd, key: "category", expected: "child", found: true},
+		{cmd: grandchildCmd, key: "team", expected: "core", found: true},
+		{cmd: grandchildCmd, key: "missing", expected: "", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.cmd.Name()+" "+tc.key, func(t *testing.T) {
+			value, found := tc.cmd.ResolveAnnotation(tc.key)
+			testutil.AssertEqual(t, tc.expected, value)
+			testutil.AssertEqual(t, tc.found, found)
+		})
+	}
+}
+
+func TestResolveAnnotationFollowsParents(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:                    "root",
+		RunE:                   noopRun,
+		InheritableAnnotations: map[string]string{"category": "root"},
+	}
+	otherCmd := &zulu.Command{
+		Use:                    "other",
+		RunE:                   noopRun,
+		InheritableAnnotations: map[string]string{"category": "other"},
+	}
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	rootCmd.AddCommand(childCmd, otherCmd)
+
+	testutil.AssertEqual(t, 0, len(childCmd.InheritableAnnotations))
+
+	rootCmd.InheritableAnnotations["category"] = "changed"
+	value, _ := childCmd.ResolveAnnotation("category")
+	testutil.AssertEqual(t, "changed", value)
+
+	rootCmd.RemoveCommand(childCmd)
+	otherCmd.AddCommand(childCmd)
+	value, _ = childCmd.ResolveAnnotation("category")
+	testutil.AssertEqual(t, "other", value)
+}
+
+func TestEnableTrailingHelpWord(t *testing.T) {
+	testCases := []struct {
+		name     string
+		enabled  bool
+		args     []string
+		expected string
+		gotArgs  string
+		gotName  string
+	}{
+		{name: "help of child", enabled: true, args: []string{"child1", "help"}, expected: "child1 long"},
+		{name: "help of grandchild", enabled: true, args: []string{"child1", "child3", "help"}, expected: "child3 long"},
+		{name: "help of command with args", enabled: true, args: []string{"child2", "arg", "help"}, expected: "child2 long"},
+		{name: "help after bool flag", enabled: true, args: []string{"child2", "--force", "help"}, expected: "child2 long"},
+		{name: "help command", enabled: true, args: []string{"help", "child1"}, expected: "child1 long"},
+		{name: "after dashes", enabled: true, args: []string{"child2", "--", "help"}, gotArgs: "help"},
+		{name: "flag value", enabled: true, args: []string{"child2", "--name", "help"}, gotName: "help"},
+		{name: "shorthand flag value", enabled: true, args: []string{"child2", "-n", "help"}, gotName: "help"},
+		{name: "flag parsing disabled", enabled: true, args: []string{"child4", "help"}, gotArgs: "help"},
+		{name: "disabled", enabled: false, args: []string{"child2", "help"}, gotArgs: "help"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotArgs []string
+			var gotName string
+			argsRun := func(_ *zulu.Command, args []string) error { gotArgs = args; return nil }
+
+			rootCmd := &zulu.Command{Use: "root", RunE: noopRun, EnableTrailingHelpWord: tc.enabled}
+			child1Cmd := &zulu.Command{Use: "child1", Long: "child1 long", RunE: noopRun}
+			child1Cmd.AddCommand(&zulu.Command{Use: "child3", Long: "child3 long", RunE: noopRun})
+			child2Cmd := &zulu.Command{Use: "child2", Long: "child2 long", Args: zulu.ArbitraryArgs, RunE: argsRun}
+			child2Cmd.Flags().StringVar(&gotName, "name", "", "name", zflag.OptShorthand('n'))
+			child2Cmd.Flags().Bool("force", false, "force")
+			child4Cmd := &zulu.Command{Use: "child4", DisableFlagParsing: true, RunE: argsRun}
+			rootCmd.AddCommand(child1Cmd, child2Cmd, child4Cmd)
+
+			output, err := executeCommand(rootCmd, tc.args...)
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			if tc.expected != "" {
+				testutil.AssertContains(t, output, tc.expected)
+			}
+			testutil.AssertEqual(t, tc.gotArgs, strings.Join(gotArgs, " "))
+			testutil.AssertEqual(t, tc.gotName, gotName)
+		})
+	}
+}
+
 func TestPersistentFlagsOnChild(t *testing.T) {
 	var childCmdArgs []string
 	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
@@ -719,6 +1128,76 @@ Global Flags:
 	testutil.AssertEqualf(t, expected, rmCarriageRet(got), "Unexpected help text")
 }
 
+func TestHelpOmitsEmptySections(t *testing.T) {
+	parent := &zulu.Command{Use: "parent", RunE: noopRun}
+	child := &zulu.Command{Use: "child", RunE: noopRun}
+	parent.AddCommand(child)
+
+	got, err := executeCommand(parent, "help", "child")
+	testutil.AssertNilf(t, err, "Unexpected error")
+
+	expected := `Usage:
+  parent child [flags]
+
+Flags:
+  -h, --help   help for child
+`
+	testutil.AssertEqualf(t, expected, rmCarriageRet(got), "Unexpected help text")
+
+	// A group only holding hidden flags must not get a heading either.
+	parent.PersistentFlags().Bool("secret", false, "hidden usage", zflag.OptHidden())
+	parent.PersistentFlags().Bool("verbose", false, "verbose usage", zflag.OptGroup("Output"))
+
+	got, err = executeCommand(parent, "help", "child")
+	testutil.AssertNilf(t, err, "Unexpected error")
+
+	expected = `Usage:
+  parent child [flags]
+
+Flags:
+  -h, --help   help for child
+
+Global Output Flags:
+      --verbose   verbose usage
+`
+	testutil.AssertEqualf(t, expected, rmCarriageRet(got), "Unexpected help text")
+	testutil.AssertNotContains(t, got, "Global Flags:")
+	testutil.AssertNotContains(t, got, "Examples:")
+	testutil.AssertNotContains(t, got, "Aliases:")
+	testutil.AssertNotContains(t, got, "Additional help topics:")
+}
+
+func TestSetFlagUsageFormatter(t *testing.T) {
+	parent := &zulu.Command{Use: "parent", RunE: noopRun}
+	child := &zulu.Command{Use: "child", RunE: noopRun}
+	parent.AddCommand(child)
+	parent.PersistentFlags().String("output", "json", "output format", zflag.OptGroup("Output"))
+	child.Flags().Int("count", 1, "number of items")
+	child.Flags().Bool("internal", false, "not for users")
+
+	parent.SetFlagUsageFormatter(func(flag *zflag.Flag) string {
+		if flag.Name == "internal" {
+			return ""
+		}
+		return fmt.Sprintf("  %s=%s: %s", flag.Name, flag.DefValue, flag.Usage)
+	})
+
+	got, err := executeCommand(parent, "help", "child")
+	testutil.AssertNilf(t, err, "Unexpected error")
+
+	expected := `Usage:
+  parent child [flags]
+
+Flags:
+  count=1: number of items
+  help=false: help for child
+
+Global Output Flags:
+  output=json: output format
+`
+	testutil.AssertEqualf(t, expected, rmCarriageRet(got), "Unexpected help text")
+}
+
 func TestSetHelpCommand(t *testing.T) {
 	c := &zulu.Command{Use: "c", RunE: noopRun}
 	c.AddCommand(&zulu.Command{Use: "empty", RunE: noopRun})
@@ -755,6 +1234,200 @@ func TestHelpFlagExecutedOnChild(t *testing.T) {
 	testutil.AssertContains(t, output, childCmd.Long)
 }
 
+func TestMinimal(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	internalCmd := &zulu.Command{Use: "internal", Minimal: true, RunE: noopRun}
+	subCmd := &zulu.Command{Use: "sub", RunE: noopRun}
+	internalCmd.AddCommand(subCmd)
+	rootCmd.AddCommand(internalCmd)
+
+	_, err := executeCommand(rootCmd, "internal", "sub")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqualf(t, (*zflag.Flag)(nil), subCmd.Flags().Lookup("help"), "Expected no help flag on a minimal subtree")
+
+	output, err := executeCommand(rootCmd, "internal", "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqualf(t, (*zflag.Flag)(nil), internalCmd.Flags().Lookup("help"), "Expected no help flag on a minimal command")
+	testutil.AssertNotContains(t, output, "help for")
+
+	for _, cmd := range internalCmd.Commands() {
+		testutil.AssertEqualf(t, false, cmd.Name() == "help", "Expected no help command on a minimal subtree")
+	}
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertNotContains(t, output, "internal")
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "internal", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, ":4\nCompletion ended with directive: ShellCompDirectiveNoFileComp\n", output)
+
+	minimalRoot := &zulu.Command{Use: "root", Minimal: true, RunE: noopRun}
+	minimalRoot.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+	_, err = executeCommand(minimalRoot, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	for _, cmd := range minimalRoot.Commands() {
+		testutil.AssertEqualf(t, "child", cmd.Name(), "Expected no help nor completion command on a minimal root")
+	}
+}
+
+func TestReturnErrOnHelp(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	childCmd := &zulu.Command{Use: "child", Long: "Long description", RunE: noopRun}
+	rootCmd.AddCommand(childCmd)
+
+	output, err := executeCommand(rootCmd, "child", "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, output, childCmd.Long)
+
+	rootCmd = &zulu.Command{Use: "root", RunE: noopRun, ReturnErrOnHelp: true}
+	childCmd = &zulu.Command{Use: "child", Long: "Long description", RunE: noopRun}
+	rootCmd.AddCommand(childCmd)
+
+	_, err = executeCommand(rootCmd, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	output, err = executeCommand(rootCmd, "child", "--help")
+	testutil.AssertEqualf(t, true, errors.Is(err, zulu.ErrHelpRequested), "Expected ErrHelpRequested, got %v", err)
+	testutil.AssertContains(t, output, childCmd.Long)
+	testutil.AssertNotContains(t, output, "Error:")
+}
+
+type exitCodeError struct {
+	code int
+}
+
+func (e exitCodeError) Error() string { return fmt.Sprintf("exit code %d", e.code) }
+func (e exitCodeError) ExitCode() int { return e.code }
+
+func TestExecuteWithExitCode(t *testing.T) {
+	tests := map[string]struct {
+		runE     zulu.HookFuncE
+		expected int
+	}{
+		"success": {
+			runE:     noopRun,
+			expected: 0,
+		},
+		"error": {
+			runE:     func(*zulu.Command, []string) error { return errors.New("failed") },
+			expected: 1,
+		},
+		"exit code error": {
+			runE:     func(*zulu.Command, []string) error { return exitCodeError{code: 3} },
+			expected: 3,
+		},
+		"wrapped exit code error": {
+			runE:     func(*zulu.Command, []string) error { return fmt.Errorf("failed: %w", exitCodeError{code: 4}) },
+			expected: 4,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rootCmd := &zulu.Command{Use: "root", SilenceUsage: true, RunE: tc.runE}
+			buf := new(bytes.Buffer)
+			rootCmd.SetOut(buf)
+			rootCmd.SetErr(buf)
+			rootCmd.SetArgs([]string{})
+
+			testutil.AssertEqual(t, tc.expected, rootCmd.ExecuteWithExitCode())
+			if tc.expected != 0 {
+				testutil.AssertContains(t, buf.String(), "Error:")
+			}
+		})
+	}
+
+	rootCmd := &zulu.Command{
+		Use:           "root",
+		SilenceErrors: true,
+		RunE:          func(*zulu.Command, []string) error { return exitCodeError{code: 2} },
+	}
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{})
+	testutil.AssertEqual(t, 2, rootCmd.ExecuteWithExitCode())
+	testutil.AssertNotContains(t, buf.String(), "Error:")
+}
+
+func TestPropagateSentinelErrors(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Version: "1.0.0", RunE: noopRun}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	output, err := executeCommand(rootCmd, "--version")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, output, "root version 1.0.0")
+
+	rootCmd = &zulu.Command{Use: "root", Version: "1.0.0", RunE: noopRun, PropagateSentinelErrors: true}
+	childCmd := &zulu.Command{Use: "child", Long: "Long description", RunE: noopRun}
+	rootCmd.AddCommand(childCmd)
+
+	output, err = executeCommand(rootCmd, "--version")
+	testutil.AssertEqualf(t, true, errors.Is(err, zulu.ErrVersion), "Expected ErrVersion, got %v", err)
+	testutil.AssertContains(t, output, "root version 1.0.0")
+	testutil.AssertNotContains(t, output, "Error:")
+
+	output, err = executeCommand(rootCmd, "child", "--help")
+	testutil.AssertEqualf(t, true, errors.Is(err, zulu.ErrHelpRequested), "Expected ErrHelpRequested, got %v", err)
+	testutil.AssertEqualf(t, true, errors.Is(err, zflag.ErrHelp), "Expected ErrHelp, got %v", err)
+	testutil.AssertContains(t, output, childCmd.Long)
+	testutil.AssertNotContains(t, output, "Error:")
+}
+
+func TestErrShowHelp(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	childCmd := &zulu.Command{
+		Use:  "child",
+		Long: "Long description",
+		RunE: func(cmd *zulu.Command, args []string) error {
+			if len(args) == 0 {
+				return zulu.ErrShowHelp
+			}
+			return nil
+		},
+	}
+	rootCmd.AddCommand(childCmd)
+
+	output, err := executeCommand(rootCmd, "child")
+	testutil.AssertEqualf(t, true, errors.Is(err, zulu.ErrShowHelp), "Expected ErrShowHelp, got %v", err)
+	testutil.AssertContains(t, output, childCmd.Long)
+	testutil.AssertContains(t, output, "Usage:")
+	testutil.AssertNotContains(t, output, "Error:")
+
+	rootCmd.WrapRunErrors = true
+	output, err = executeCommand(rootCmd, "child")
+	testutil.AssertEqualf(t, true, errors.Is(err, zulu.ErrShowHelp), "Expected ErrShowHelp, got %v", err)
+	testutil.AssertContains(t, output, childCmd.Long)
+	testutil.AssertNotContains(t, output, "Error:")
+
+	_, err = executeCommand(rootCmd, "child", "arg")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+}
+
+func TestRequireSubcommand(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root"}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	output, err := executeCommand(rootCmd)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertNotContains(t, output, "Error:")
+
+	rootCmd = &zulu.Command{Use: "root", RequireSubcommand: true}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	output, err = executeCommand(rootCmd)
+	testutil.AssertEqualf(t, true, errors.Is(err, zulu.ErrSubcommandRequired), "Expected ErrSubcommandRequired, got %v", err)
+	testutil.AssertContains(t, output, "Error: a subcommand is required")
+	testutil.AssertContains(t, output, "Usage:")
+
+	rootCmd = &zulu.Command{Use: "root", RequireSubcommand: true}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	_, err = executeCommand(rootCmd, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+}
+
 // TestHelpFlagInHelp checks,
 // if '--help' flag is shown in help for child (executing `parent help child`),
 // that has no other flags.
@@ -950,6 +1623,181 @@ func TestVisitParents(t *testing.T) {
 	testutil.AssertEqualf(t, 0, total, "Unexpected parent visits")
 }
 
+func TestFindPath(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	childCmd := &zulu.Command{Use: "child", Aliases: []string{"c"}, RunE: noopRun}
+	childCmd.Flags().String("name", "", "")
+	grandchildCmd := &zulu.Command{Use: "grandchild", RunE: noopRun}
+	childCmd.AddCommand(grandchildCmd)
+	rootCmd.AddCommand(childCmd)
+
+	names := func(path []*zulu.Command) string {
+		var names []string
+		for _, cmd := range path {
+			names = append(names, cmd.Name())
+		}
+		return strings.Join(names, " ")
+	}
+
+	path, args, err := rootCmd.FindPath([]string{"c", "--name", "x", "grandchild", "arg"})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "root child grandchild", names(path))
+	testutil.AssertEqual(t, "--name x arg", strings.Join(args, " "))
+
+	path, _, err = rootCmd.FindPath([]string{})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "root", names(path))
+
+	// The parents are included when searching from a child
+	path, _, err = childCmd.FindPath([]string{"grandchild"})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "root child grandchild", names(path))
+}
+
+func TestVisitCommands(t *testing.T) {
+	c := &zulu.Command{Use: "app"}
+	subB := &zulu.Command{Use: "subB"}
+	subA := &zulu.Command{Use: "subA"}
+	dsub := &zulu.Command{Use: "dsub"}
+	subB.AddCommand(dsub)
+	c.AddCommand(subB, subA)
+
+	var visited []string
+	c.VisitCommands(func(x *zulu.Command) {
+		visited = append(visited, x.Name())
+	})
+	testutil.AssertEqual(t, "app subA subB dsub", strings.Join(visited, " "))
+
+	total := 0
+	subB.VisitCommands(func(x *zulu.Command) {
+		total++
+	})
+	testutil.AssertEqualf(t, 2, total, "Unexpected command visits")
+}
+
+func TestWalk(t *testing.T) {
+	c := &zulu.Command{Use: "app"}
+	subB := &zulu.Command{Use: "subB", RunE: noopRun}
+	subA := &zulu.Command{Use: "subA"}
+	dsub := &zulu.Command{Use: "dsub"}
+	subA.AddCommand(dsub)
+	c.AddCommand(subB, subA)
+
+	var visited []string
+	err := c.Walk(func(x *zulu.Command) error {
+		visited = append(visited, x.Name())
+		return nil
+	})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "app subA dsub subB", strings.Join(visited, " "))
+
+	// Every leaf must be runnable
+	visited = nil
+	err = c.Walk(func(x *zulu.Command) error {
+		visited = append(visited, x.Name())
+		if !x.Runnable() && !x.HasSubCommands() {
+			return fmt.Errorf("%q is neither runnable nor has subcommands", x.CommandPath())
+		}
+		return nil
+	})
+	testutil.AssertNotNilf(t, err, "Expected an error")
+	testutil.AssertEqual(t, `"app subA dsub" is neither runnable nor has subcommands`, err.Error())
+	testutil.AssertEqual(t, "app subA dsub", strings.Join(visited, " "))
+}
+
+func TestSetSuggestionFunc(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	timesCmd := &zulu.Command{Use: "times", RunE: noopRun}
+	rootCmd.AddCommand(
+		timesCmd,
+		&zulu.Command{Use: "tools", RunE: noopRun},
+		&zulu.Command{Use: "hidden", Hidden: true, RunE: noopRun},
+	)
+	timesCmd.AddCommand(&zulu.Command{Use: "sub", RunE: noopRun})
+
+	var candidates []string
+	rootCmd.SetSuggestionFunc(func(typed string, cands []string) []string {
+		candidates = cands
+		// Suggest the candidates sharing the last letter of the typed name
+		var suggestions []string
+		for _, c := range cands {
+			if c[len(c)-1] == typed[len(typed)-1] {
+				suggestions = append(suggestions, c)
+			}
+		}
+		return suggestions
+	})
+
+	testutil.AssertEqual(t, "times tools", strings.Join(rootCmd.SuggestionsFor("xxs"), " "))
+	testutil.AssertEqual(t, "times tools", strings.Join(candidates, " "))
+
+	output, err := executeCommand(rootCmd, "xxs")
+	testutil.AssertNotNilf(t, err, "Expected an error")
+	testutil.AssertContains(t, output, "Did you mean this?\n\ttimes\n\ttools\n")
+
+	// The function is inherited, and MaxSuggestions still applies
+	timesCmd.MaxSuggestions = 1
+	testutil.AssertEqual(t, "sub", strings.Join(timesCmd.SuggestionsFor("b"), " "))
+
+	rootCmd.MaxSuggestions = 1
+	testutil.AssertEqual(t, "times", strings.Join(rootCmd.SuggestionsFor("xxs"), " "))
+
+	// nil restores the default
+	rootCmd.SetSuggestionFunc(nil)
+	testutil.AssertEqual(t, "times", strings.Join(rootCmd.SuggestionsFor("time"), " "))
+}
+
+func TestSetUnknownCommandFunc(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	rootCmd.PersistentFlags().String("region", "", "region")
+	childCmd := &zulu.Command{Use: "child", Args: zulu.NoArgs, RunE: noopRun}
+	childCmd.AddCommand(&zulu.Command{Use: "sub", RunE: noopRun})
+	rootCmd.AddCommand(childCmd)
+
+	var gotCmd, gotName string
+	var gotArgs []string
+	handlerErr := errors.New("plugin failed")
+	rootCmd.SetUnknownCommandFunc(func(cmd *zulu.Command, name string, args []string) error {
+		gotCmd, gotName, gotArgs = cmd.Name(), name, args
+		if name == "failing" {
+			return handlerErr
+		}
+		return nil
+	})
+
+	output, err := executeCommand(rootCmd, "plugin", "--flag", "arg")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "", output)
+	testutil.AssertEqual(t, "root", gotCmd)
+	testutil.AssertEqual(t, "plugin", gotName)
+	testutil.AssertEqual(t, "--flag arg", strings.Join(gotArgs, " "))
+
+	// Only the arguments following the name are given, not the flags before it
+	_, err = executeCommand(rootCmd, "--verbose", "--region", "eu", "plugin", "--flag", "plugin")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "plugin", gotName)
+	testutil.AssertEqual(t, "--flag plugin", strings.Join(gotArgs, " "))
+
+	// The error of the function is returned as is
+	output, err = executeCommand(rootCmd, "failing")
+	testutil.AssertEqual(t, handlerErr, err)
+	testutil.AssertEqual(t, "", output)
+
+	// The function is inherited, and also handles the NoArgs errors
+	_, err = executeCommand(rootCmd, "child", "other", "arg")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "child", gotCmd)
+	testutil.AssertEqual(t, "other", gotName)
+	testutil.AssertEqual(t, "arg", strings.Join(gotArgs, " "))
+
+	// Without a function, the unknown command is reported
+	rootCmd.SetUnknownCommandFunc(nil)
+	output, err = executeCommand(rootCmd, "plugin")
+	testutil.AssertNotNilf(t, err, "Expected an error")
+	testutil.AssertContains(t, output, `unknown command "plugin" for "root"`)
+}
+
 func TestSuggestions(t *testing.T) {
 	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
 	timesCmd := &zulu.Command{
@@ -1001,6 +1849,97 @@ Run 'root --help' for usage.
 	}
 }
 
+func TestMaxSuggestions(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun, MaxSuggestions: 2}
+	for _, name := range []string{"rebase", "remix", "remote", "remove"} {
+		rootCmd.AddCommand(&zulu.Command{Use: name, RunE: noopRun})
+	}
+
+	output, _ := executeCommand(rootCmd, "remov")
+	expected := `Error: unknown command "remov" for "root"
+
+Did you mean this?
+	remove
+	remix
+
+Run 'root --help' for usage.
+`
+	testutil.AssertEqual(t, expected, output)
+
+	rootCmd.MaxSuggestions = 0
+	testutil.AssertEqual(t, "remove remix remote", strings.Join(rootCmd.SuggestionsFor("remov"), " "))
+}
+
+func TestShorthandFlagSuggestions(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().Bool("verbose", false, "verbose output", zflag.OptShorthand('v'))
+	rootCmd.Flags().Bool("xml", false, "xml output")
+
+	tests := map[string]string{
+		"-V":   "-v (--verbose)",
+		"-xm":  "--xml",
+		"-xml": "--xml",
+		"-q":   "",
+	}
+
+	for typo, suggestion := range tests {
+		for _, suggestionsDisabled := range []bool{true, false} {
+			rootCmd.DisableSuggestions = suggestionsDisabled
+
+			_, err := executeCommand(rootCmd, typo)
+			testutil.AssertErrf(t, err, "expected an error for %q", typo)
+			testutil.AssertContains(t, err.Error(), "unknown shorthand flag")
+
+			if suggestion == "" || suggestionsDisabled {
+				testutil.AssertNotContains(t, err.Error(), "Did you mean this?")
+				continue
+			}
+
+			testutil.AssertContains(t, err.Error(), "\n\nDid you mean this?\n\t"+suggestion+"\n")
+		}
+	}
+}
+
+func TestLongFlagSuggestions(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().String("color", "", "color output")
+	rootCmd.Flags().Bool("verbose", false, "verbose output")
+	rootCmd.Flags().Bool("secret", false, "secret output", zflag.OptHidden())
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	rootCmd.AddCommand(childCmd)
+
+	tests := map[string]string{
+		"--colr":      "--color",
+		"--colr=red":  "--color",
+		"--verb":      "--verbose",
+		"--VERBOSE":   "--verbose",
+		"--secrte":    "",
+		"--something": "",
+	}
+
+	for typo, suggestion := range tests {
+		for _, suggestionsDisabled := range []bool{true, false} {
+			rootCmd.DisableSuggestions = suggestionsDisabled
+
+			_, err := executeCommand(rootCmd, typo)
+			testutil.AssertErrf(t, err, "expected an error for %q", typo)
+			testutil.AssertContains(t, err.Error(), "unknown flag")
+
+			if suggestion == "" || suggestionsDisabled {
+				testutil.AssertNotContains(t, err.Error(), "Did you mean this?")
+				continue
+			}
+
+			testutil.AssertContains(t, err.Error(), "\n\nDid you mean this?\n\t"+suggestion+"\n")
+		}
+	}
+
+	// Inherited flags are suggested too
+	_, err := executeCommand(rootCmd, "child", "--colour")
+	testutil.AssertErrf(t, err, "expected an error")
+	testutil.AssertContains(t, err.Error(), "\n\nDid you mean this?\n\t--color\n")
+}
+
 func TestRemoveCommand(t *testing.T) {
 	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
 	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
@@ -1153,6 +2092,7 @@ func TestPersistentHooks(t *testing.T) {
 
 	parentCmd.OnPersistentInitialize(getTestHookFn("persParentPersInitArgs"))
 	parentCmd.OnPersistentInitialize(getTestHookFn("persParentInitArgs"))
+	parentCmd.OnPersistentFlagsParsed(getTestHookFn("persParentFlagsParsedArgs"))
 	parentCmd.OnPersistentPreRun(getTestHookFn("persParentPersPreArgs"))
 	parentCmd.OnPreRun(getTestHookFn("persParentPreArgs"))
 	parentCmd.OnRun(getTestHookFn("persParentRunArgs"))
@@ -1201,6 +2141,7 @@ func TestPersistentHooks(t *testing.T) {
 		// Test On*Run hooks
 		{"persParentPersInitArgs", ""},
 		{"persParentInitArgs", ""},
+		{"persParentFlagsParsedArgs", onetwo},
 		{"persParentPersPreArgs", onetwo},
 		{"persParentPreArgs", ""},
 		{"persParentRunArgs", ""},
@@ -1226,8 +2167,78 @@ func TestPersistentHooks(t *testing.T) {
 			continue
 		}
 
-		testutil.AssertEqualf(t, v.expected, got, "Expected %q %s, got %q", v.expected, v.name, got)
+		testutil.AssertEqualf(t, v.expected, got, "Expected %q %s, got %q", v.expected, v.name, got)
+	}
+}
+
+func TestOnPersistentFlagsParsed(t *testing.T) {
+	var calls []string
+	record := func(name string) zulu.HookFuncE {
+		return func(cmd *zulu.Command, args []string) error {
+			verbose, err := cmd.Flags().GetBool("verbose")
+			if err != nil {
+				return err
+			}
+			calls = append(calls, fmt.Sprintf("%s(verbose=%t)", name, verbose))
+			return nil
+		}
+	}
+
+	rootCmd := &zulu.Command{
+		Use:               "root",
+		PersistentPreRunE: record("persistentPreRun"),
+		RunE:              noopRun,
+	}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	rootCmd.OnPersistentInitialize(func(cmd *zulu.Command, args []string) error {
+		calls = append(calls, "initialize")
+		return nil
+	})
+	rootCmd.OnPersistentFlagsParsed(record("flagsParsed"))
+	childCmd := &zulu.Command{
+		Use:     "child",
+		PreRunE: record("preRun"),
+		RunE:    noopRun,
 	}
+	childCmd.OnPersistentFlagsParsed(record("childFlagsParsed"))
+	rootCmd.AddCommand(childCmd)
+
+	_, err := executeCommand(rootCmd, "child", "--verbose")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, strings.Join([]string{
+		"initialize",
+		"childFlagsParsed(verbose=true)",
+		"flagsParsed(verbose=true)",
+		"persistentPreRun(verbose=true)",
+		"preRun(verbose=true)",
+	}, " "), strings.Join(calls, " "))
+
+	// They run before the help is shown
+	calls = nil
+	childCmd = &zulu.Command{Use: "child", RunE: noopRun}
+	rootCmd = &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	rootCmd.OnPersistentFlagsParsed(record("flagsParsed"))
+	rootCmd.AddCommand(childCmd)
+
+	_, err = executeCommand(rootCmd, "child", "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "flagsParsed(verbose=false)", strings.Join(calls, " "))
+
+	// They run after the config file is applied
+	calls = nil
+	childCmd = &zulu.Command{Use: "child", RunE: noopRun}
+	rootCmd = &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	rootCmd.OnPersistentFlagsParsed(record("flagsParsed"))
+	rootCmd.EnableConfigFlag("config", func(_ string) (map[string]string, error) {
+		return map[string]string{"verbose": "true"}, nil
+	})
+	rootCmd.AddCommand(childCmd)
+
+	_, err = executeCommand(rootCmd, "child", "--config", "config.yaml")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "flagsParsed(verbose=true)", strings.Join(calls, " "))
 }
 
 // Related to https://github.com/spf13/cobra/issues/521.
@@ -1431,6 +2442,229 @@ func TestUsageWithGroup(t *testing.T) {
 	testutil.AssertContains(t, output, "\ngroup2\n  cmd2")
 }
 
+func TestGroupsWithCommands(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:               "root",
+		CompletionOptions: zulu.CompletionOptions{DisableDefaultCmd: true},
+		RunE:              noopRun,
+	}
+	rootCmd.AddGroup(zulu.Group{Group: "empty", Title: "Empty"}, zulu.Group{Group: "admin", Title: "Admin"})
+	rootCmd.AddCommand(
+		&zulu.Command{Use: "users", Group: "admin", RunE: noopRun},
+		&zulu.Command{Use: "roles", Group: "admin", RunE: noopRun},
+		&zulu.Command{Use: "secret", Group: "admin", Hidden: true, RunE: noopRun},
+		&zulu.Command{Use: "old", Group: "empty", Deprecated: "don't", RunE: noopRun},
+		&zulu.Command{Use: "version", RunE: noopRun},
+	)
+	_, err := executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	var groups []string
+	for _, group := range rootCmd.GroupsWithCommands() {
+		names := make([]string, 0, len(group.Commands))
+		for _, cmd := range group.Commands {
+			names = append(names, cmd.Name())
+		}
+		groups = append(groups, fmt.Sprintf("%q %q: %s", group.Group.Group, group.Group.Title, strings.Join(names, " ")))
+	}
+	expected := strings.Join([]string{
+		`"" "": help version`,
+		`"admin" "Admin": roles users`,
+	}, "\n")
+	testutil.AssertEqual(t, expected, strings.Join(groups, "\n"))
+}
+
+func TestUsageWithGroupTitleFunc(t *testing.T) {
+	var rootCmd = &zulu.Command{
+		Use:               "root",
+		Short:             "test",
+		CompletionOptions: zulu.CompletionOptions{DisableDefaultCmd: true},
+		RunE:              noopRun,
+	}
+	rootCmd.AddGroup(zulu.Group{Group: "admin", Title: "Admin Commands"})
+	rootCmd.AddCommand(&zulu.Command{Use: "cmd1", Group: "admin", RunE: noopRun})
+	rootCmd.AddCommand(&zulu.Command{Use: "cmd2", Group: "other", RunE: noopRun})
+
+	lang := "en"
+	rootCmd.SetGroupTitleFunc(func(groupID string) string {
+		if groupID == "admin" && lang == "fr" {
+			return "Commandes d'administration"
+		}
+		for _, group := range rootCmd.Groups() {
+			if group.Group == groupID {
+				return group.Title
+			}
+		}
+		return groupID
+	})
+
+	output, err := executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error")
+	output = rmCarriageRet(output)
+	testutil.AssertContains(t, output, "\nAdmin Commands\n  cmd1")
+	testutil.AssertContains(t, output, "\nother\n  cmd2")
+
+	// The title is computed each time the usage is rendered.
+	lang = "fr"
+	output, err = executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error")
+	output = rmCarriageRet(output)
+	testutil.AssertContains(t, output, "\nCommandes d'administration\n  cmd1")
+	testutil.AssertContains(t, output, "\nother\n  cmd2")
+}
+
+func TestSetUsageSectionOrder(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:               "root",
+		Example:           "root child",
+		CompletionOptions: zulu.CompletionOptions{DisableDefaultCmd: true},
+		RunE:              noopRun,
+	}
+	childCmd := &zulu.Command{Use: "child", Example: "root child --force", RunE: noopRun}
+	childCmd.Flags().Bool("force", false, "force")
+	rootCmd.AddCommand(childCmd)
+
+	output := rmCarriageRet(rootCmd.UsageString())
+	testutil.AssertEqualf(t, true,
+		strings.Index(output, "\nExamples:") < strings.Index(output, "\nAvailable Commands:"),
+		"Expected the default order, got:\n%s", output)
+
+	rootCmd.SetUsageSectionOrder(
+		zulu.UsageSectionCommands,
+		zulu.UsageSectionExamples,
+		zulu.UsageSectionFlags,
+		zulu.UsageSectionGlobalFlags,
+	)
+	output = rmCarriageRet(rootCmd.UsageString())
+	expected := `Usage:
+  root
+  root [command]
+
+Available Commands:
+  child       
+
+Examples:
+  root child
+
+Use "root [command] --help" for more information about a command.
+`
+	testutil.AssertEqual(t, expected, output)
+
+	// The order is inherited by the children, and the sections left out are not rendered.
+	rootCmd.SetUsageSectionOrder(zulu.UsageSectionFlags, zulu.UsageSectionExamples)
+	output = rmCarriageRet(childCmd.UsageString())
+	expected = `Usage:
+  root child [flags]
+
+Flags:
+      --force   force
+
+Examples:
+  root child --force
+`
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestSetUsageTemplateFuncs(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Short: "root short", RunE: noopRun}
+	childCmd := &zulu.Command{Use: "child", Short: "child short", RunE: noopRun}
+	rootCmd.AddCommand(childCmd)
+
+	rootCmd.SetUsageTemplate(`{{ shout .Name }}`)
+	rootCmd.SetUsageTemplateFuncs(template.FuncMap{
+		"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+	})
+	testutil.AssertEqual(t, "ROOT!", rootCmd.UsageString())
+	testutil.AssertEqual(t, "CHILD!", childCmd.UsageString())
+
+	// The closest command takes precedence
+	childCmd.SetUsageTemplateFuncs(template.FuncMap{
+		"shout": func(s string) string { return s + "?" },
+	})
+	testutil.AssertEqual(t, "child?", childCmd.UsageString())
+
+	rootCmd.SetHelpTemplate(`{{ whisper .Short }}`)
+	rootCmd.SetHelpTemplateFuncs(template.FuncMap{"whisper": strings.ToLower})
+	rootCmd.Short = "ROOT SHORT"
+	output, err := executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "root short", output)
+}
+
+func TestSetTemplateE(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Version: "1.0.0", RunE: noopRun}
+
+	err := rootCmd.SetUsageTemplateE(`{{ .Name `)
+	testutil.AssertErrf(t, err, "Expected an error for a malformed usage template")
+	testutil.AssertContains(t, err.Error(), "invalid usage template: ")
+
+	err = rootCmd.SetHelpTemplateE(`{{ unknown .Name }}`)
+	testutil.AssertErrf(t, err, "Expected an error for an unknown function")
+	testutil.AssertContains(t, err.Error(), `invalid help template: `)
+	testutil.AssertContains(t, err.Error(), `function "unknown" not defined`)
+
+	err = rootCmd.SetVersionTemplateE(`{{ end }}`)
+	testutil.AssertErrf(t, err, "Expected an error for a malformed version template")
+	testutil.AssertContains(t, err.Error(), "invalid version template: ")
+
+	// The malformed templates were not set
+	output, err := executeCommand(rootCmd, "--version")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "root version 1.0.0\n", output)
+
+	rootCmd.SetHelpTemplateFuncs(template.FuncMap{"upper": strings.ToUpper})
+	err = rootCmd.SetHelpTemplateE(`{{ upper .Name }}`)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertNilf(t, rootCmd.SetUsageTemplateE(`{{ rpad .Name 6 }}|`), "Unexpected error")
+	testutil.AssertNilf(t, rootCmd.SetVersionTemplateE(`{{ .Version }}`), "Unexpected error")
+
+	output, err = executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "ROOT", output)
+	testutil.AssertEqual(t, "root  |", rootCmd.UsageString())
+}
+
+func TestStructuredExamples(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:  "root",
+		RunE: noopRun,
+		Examples: []zulu.CommandExample{
+			{Command: "root --name john", Description: "Greet john"},
+			{Command: "root"},
+			{Command: "root --name jane --loud", Description: "Greet jane loudly"},
+		},
+		// Ignored as Examples are set
+		Example: "root --legacy",
+	}
+	rootCmd.Flags().String("name", "", "name")
+	rootCmd.Flags().Bool("loud", false, "loud")
+
+	testutil.AssertEqual(t, true, rootCmd.HasExample())
+	testutil.AssertEqual(t, "# Greet john\nroot --name john\n\nroot\n\n# Greet jane loudly\nroot --name jane --loud", rootCmd.ExampleText())
+
+	expected := `Usage:
+  root [flags]
+
+Examples:
+  # Greet john
+  root --name john
+
+  root
+
+  # Greet jane loudly
+  root --name jane --loud
+
+Flags:
+      --loud          loud
+      --name string   name
+`
+	testutil.AssertEqual(t, expected, rmCarriageRet(rootCmd.UsageString()))
+
+	rootCmd.Examples = nil
+	testutil.AssertEqual(t, "root --legacy", rootCmd.ExampleText())
+	testutil.AssertContains(t, rootCmd.UsageString(), "Examples:\n  root --legacy\n")
+}
+
 func TestUsageHelpGroup(t *testing.T) {
 	var rootCmd = &zulu.Command{
 		Use:               "root",
@@ -1464,6 +2698,88 @@ func TestAddGroup(t *testing.T) {
 	testutil.AssertContains(t, output, "\nTest group\n  cmd")
 }
 
+func TestAddGroupWithoutCommands(t *testing.T) {
+	var rootCmd = &zulu.Command{Use: "root", Short: "test", RunE: noopRun}
+
+	rootCmd.AddGroup(
+		zulu.Group{Group: "group", Title: "Test group"},
+		zulu.Group{Group: "empty", Title: "Empty group"},
+		zulu.Group{Group: "hidden", Title: "Hidden group"},
+	)
+	rootCmd.AddCommand(
+		&zulu.Command{Use: "cmd", Group: "group", RunE: noopRun},
+		&zulu.Command{Use: "secret", Group: "hidden", Hidden: true, RunE: noopRun},
+	)
+
+	output, err := executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error")
+
+	output = rmCarriageRet(output)
+	testutil.AssertContains(t, output, "\nTest group\n  cmd")
+	testutil.AssertNotContains(t, output, "Empty group")
+	testutil.AssertNotContains(t, output, "Hidden group")
+}
+
+func TestDefaultCommandGroup(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Short: "test", RunE: noopRun}
+	rootCmd.AddGroup(zulu.Group{Group: "group", Title: "Test group"})
+	rootCmd.AddCommand(
+		&zulu.Command{Use: "grouped", Group: "group", RunE: noopRun},
+		&zulu.Command{Use: "ungrouped", RunE: noopRun},
+	)
+
+	err := rootCmd.AllChildCommandsHaveGroup()
+	testutil.AssertErrf(t, err, "Expected an error for the ungrouped command")
+	testutil.AssertEqual(t, `commands [ungrouped] of "root" have no group`, err.Error())
+
+	rootCmd = &zulu.Command{Use: "root", Short: "test", RunE: noopRun, DefaultCommandGroup: "other"}
+	rootCmd.AddGroup(zulu.Group{Group: "group", Title: "Test group"})
+	rootCmd.AddCommand(
+		&zulu.Command{Use: "grouped", Group: "group", RunE: noopRun},
+		&zulu.Command{Use: "defaulted", RunE: noopRun},
+	)
+
+	output, err := executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertNilf(t, rootCmd.AllChildCommandsHaveGroup(), "Expected all the commands to have a group")
+
+	output = rmCarriageRet(output)
+	testutil.AssertContains(t, output, "\nTest group\n  grouped")
+	testutil.AssertContains(t, output, "\nother\n  completion")
+	testutil.AssertContains(t, output, "  defaulted")
+	testutil.AssertContains(t, output, "  help")
+}
+
+func TestSetFlagGroupTitle(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().String("proxy", "", "proxy usage", zflag.OptGroup("net"))
+	rootCmd.SetFlagGroupTitle("net", "Network")
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().Int("port", 0, "port usage", zflag.OptGroup("net"))
+	rootCmd.AddCommand(childCmd)
+
+	output := rmCarriageRet(rootCmd.UsageString())
+	testutil.AssertContains(t, output, "\nNetwork Flags:\n      --proxy string")
+
+	// The closest title is used for both the local and the inherited flags
+	childCmd.SetFlagGroupTitle("net", "Networking")
+	output = rmCarriageRet(childCmd.UsageString())
+	expected := `Usage:
+  root child [flags]
+
+Networking Flags:
+      --port int   port usage
+
+Global Networking Flags:
+      --proxy string   proxy usage
+`
+	testutil.AssertEqual(t, expected, output)
+
+	output = rmCarriageRet(rootCmd.UsageString())
+	testutil.AssertContains(t, output, "\nNetwork Flags:\n")
+	testutil.AssertNotContains(t, output, "Networking")
+}
+
 func TestInOutErr(t *testing.T) {
 	c := &zulu.Command{}
 	b := bytes.NewBuffer(nil)
@@ -1482,6 +2798,35 @@ func TestInOutErr(t *testing.T) {
 	testutil.AssertEqualf(t, os.Stderr, c.ErrOrStderr(), "Expected setting error to nil to revert back to stderr")
 }
 
+func TestSetInOutErr(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	rootCmd.AddCommand(childCmd)
+
+	in, out, errOut := bytes.NewBufferString("input"), new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetInOutErr(in, out, errOut)
+	testutil.AssertEqual(t, in, rootCmd.InOrStdin())
+	testutil.AssertEqual(t, out, rootCmd.OutOrStdout())
+	testutil.AssertEqual(t, errOut, rootCmd.ErrOrStderr())
+
+	// The child inherits the writers and reader it does not set
+	childErr := new(bytes.Buffer)
+	childCmd.SetInOutErr(nil, nil, childErr)
+	testutil.AssertEqual(t, in, childCmd.InOrStdin())
+	testutil.AssertEqual(t, out, childCmd.OutOrStdout())
+	testutil.AssertEqual(t, childErr, childCmd.ErrOrStderr())
+
+	childCmd.Print("out")
+	childCmd.PrintErr("err")
+	testutil.AssertEqual(t, "out", out.String())
+	testutil.AssertEqual(t, "", errOut.String())
+	testutil.AssertEqual(t, "err", childErr.String())
+
+	rootCmd.SetInOutErr(nil, nil, nil)
+	testutil.AssertEqual(t, os.Stdin, childCmd.InOrStdin())
+	testutil.AssertEqual(t, os.Stdout, childCmd.OutOrStdout())
+}
+
 func TestUsageStringRedirected(t *testing.T) {
 	c := &zulu.Command{}
 
@@ -1496,6 +2841,41 @@ func TestUsageStringRedirected(t *testing.T) {
 	testutil.AssertEqualf(t, expected, c.UsageString(), "Expected usage string to consider both stdout and stderr")
 }
 
+func TestWriteUsage(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().String("config", "", "config file")
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().Bool("force", false, "force the operation")
+	rootCmd.AddCommand(childCmd)
+
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(out)
+
+	expected := childCmd.UsageString()
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			buf := new(bytes.Buffer)
+			errs[i] = childCmd.WriteUsage(buf)
+			results[i] = buf.String()
+		}()
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		testutil.AssertNil(t, errs[i])
+		testutil.AssertEqual(t, expected, result)
+	}
+	testutil.AssertEqualf(t, "", out.String(), "WriteUsage should not write to the command output")
+}
+
 func TestCommandPrintRedirection(t *testing.T) {
 	errBuff, outBuff := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
 	root := &zulu.Command{
@@ -1582,6 +2962,38 @@ func TestSortedFlags(t *testing.T) {
 	})
 }
 
+func TestDefaultSortFlags(t *testing.T) {
+	zulu.DefaultSortFlags = false
+	defer func() { zulu.DefaultSortFlags = true }()
+
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().Bool("zeta", false, "")
+	rootCmd.PersistentFlags().Bool("alpha", false, "")
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().Bool("omega", false, "")
+	childCmd.Flags().Bool("beta", false, "")
+	rootCmd.AddCommand(childCmd)
+
+	testutil.AssertEqual(t, false, childCmd.Flags().SortFlags)
+	testutil.AssertEqual(t, false, childCmd.PersistentFlags().SortFlags)
+
+	var names []string
+	childCmd.LocalFlags().VisitAll(func(f *zflag.Flag) {
+		names = append(names, f.Name)
+	})
+	testutil.AssertEqual(t, "omega beta", strings.Join(names, " "))
+
+	names = nil
+	childCmd.InheritedFlags().VisitAll(func(f *zflag.Flag) {
+		names = append(names, f.Name)
+	})
+	testutil.AssertEqual(t, "zeta alpha", strings.Join(names, " "))
+
+	zulu.DefaultSortFlags = true
+	c := &zulu.Command{Use: "c"}
+	testutil.AssertEqual(t, true, c.Flags().SortFlags)
+}
+
 // TestMergeCommandLineToFlags checks,
 // if zflag.CommandLine is correctly merged to c.Flags() after first call
 // of c.mergePersistentFlags.
@@ -1701,6 +3113,81 @@ func TestUpdateName(t *testing.T) {
 	testutil.AssertEqualf(t, "changedName", c.Name(), "c.Name() should be updated on changed c.Use")
 }
 
+func TestAutoResetFlagsOnSetArgs(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		autoReset bool
+		expected  string
+	}{
+		{desc: "enabled", autoReset: true, expected: "name=default changed=false tags=[b]"},
+		{desc: "disabled", autoReset: false, expected: "name=first changed=true tags=[a b]"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got string
+			rootCmd := &zulu.Command{Use: "root", AutoResetFlagsOnSetArgs: tc.autoReset}
+			name := rootCmd.PersistentFlags().String("name", "default", "")
+			childCmd := &zulu.Command{Use: "child", Aliases: []string{"kid"}}
+			tags := childCmd.Flags().StringSlice("tags", nil, "")
+			childCmd.RunE = func(cmd *zulu.Command, args []string) error {
+				got = fmt.Sprintf("name=%s changed=%t tags=%v", *name, cmd.Flag("name").Changed, *tags)
+				return nil
+			}
+			rootCmd.AddCommand(childCmd)
+
+			_, err := executeCommand(rootCmd, "kid", "--name", "first", "--tags", "a")
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+			rootCmd.SetArgs(nil)
+			testutil.AssertEqual(t, "", childCmd.CalledAs())
+
+			_, err = executeCommand(rootCmd, "child", "--tags", "b")
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertEqual(t, tc.expected, got)
+			testutil.AssertEqual(t, "child", childCmd.CalledAs())
+		})
+	}
+}
+
+func TestAutoResetFlagsOnSetArgsDefaults(t *testing.T) {
+	var got string
+	rootCmd := &zulu.Command{Use: "root", AutoResetFlagsOnSetArgs: true}
+	tags := rootCmd.Flags().StringSlice("tags", []string{"a b", "c"}, "")
+	ints := rootCmd.Flags().IntSlice("ints", []int{1, 2}, "")
+	labels := rootCmd.Flags().StringToString("labels", map[string]string{"k": "v w"}, "")
+	rootCmd.RunE = func(cmd *zulu.Command, args []string) error {
+		got = fmt.Sprintf("tags=%q ints=%v labels=%q", *tags, *ints, *labels)
+		return nil
+	}
+
+	_, err := executeCommand(rootCmd, "--tags", "x", "--ints", "3", "--labels", "k=x", "--labels", "other=y")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, `tags=["x"] ints=[3] labels=map["k":"x" "other":"y"]`, got)
+
+	_, err = executeCommand(rootCmd)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, `tags=["a b" "c"] ints=[1 2] labels=map["k":"v w"]`, got)
+}
+
+func TestAutoResetFlagsOnSetArgsError(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun, AutoResetFlagsOnSetArgs: true}
+	rootCmd.Flags().Func("level", "", func(value string) error {
+		if value == "" {
+			return errors.New("empty level")
+		}
+		return nil
+	})
+
+	_, err := executeCommand(rootCmd, "--level", "debug")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	output, err := executeCommand(rootCmd)
+	testutil.AssertErrf(t, err, "Expected an error")
+	testutil.AssertEqual(t, `failed to reset flag "level": empty level`, err.Error())
+	testutil.AssertContains(t, output, `Error: failed to reset flag "level": empty level`)
+}
+
 func TestCalledAs(t *testing.T) {
 	tests := map[string]struct {
 		args []string
@@ -1900,6 +3387,51 @@ func TestSetContextPreRun(t *testing.T) {
 	testutil.AssertNilf(t, err, "Unexpected error")
 }
 
+type runError struct{ code int }
+
+func (e *runError) Error() string { return fmt.Sprintf("failed with code %d", e.code) }
+
+func TestWrapRunErrors(t *testing.T) {
+	runErr := &runError{code: 3}
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun, WrapRunErrors: true}
+	childCmd := &zulu.Command{Use: "child", RunE: func(*zulu.Command, []string) error { return runErr }}
+	rootCmd.AddCommand(childCmd)
+
+	_, err := executeCommand(rootCmd, "child")
+	testutil.AssertErrf(t, err, "Expected an error")
+	testutil.AssertEqual(t, "root child: failed with code 3", err.Error())
+	testutil.AssertEqualf(t, true, errors.Is(err, runErr), "Expected the error to wrap the original error")
+
+	var target *runError
+	testutil.AssertEqualf(t, true, errors.As(err, &target), "Expected the error to unwrap to the original type")
+	testutil.AssertEqual(t, 3, target.code)
+
+	rootCmd.WrapRunErrors = false
+	_, err = executeCommand(rootCmd, "child")
+	testutil.AssertEqual(t, "failed with code 3", err.Error())
+}
+
+func TestCommandFromContext(t *testing.T) {
+	var fromCtx *zulu.Command
+	root := &zulu.Command{
+		Use: "root",
+		PersistentPreRunE: func(cmd *zulu.Command, args []string) error {
+			fromCtx = zulu.CommandFromContext(cmd.Context())
+			return nil
+		},
+	}
+	child := &zulu.Command{Use: "child"}
+	leaf := &zulu.Command{Use: "leaf", RunE: noopRun}
+	child.AddCommand(leaf)
+	root.AddCommand(child)
+
+	_, err := executeCommand(root, "child", "leaf")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqualf(t, true, fromCtx == leaf, "expected the leaf command in the context, got %v", fromCtx)
+
+	testutil.AssertEqualf(t, true, zulu.CommandFromContext(context.Background()) == nil, "expected no command in an empty context")
+}
+
 func TestSetContextPreRunOverwrite(t *testing.T) {
 	key, val := "foo", "bar"
 	root := &zulu.Command{
@@ -2023,6 +3555,32 @@ Examples:
 				return child
 			},
 		},
+		{
+			name: "usage forms test",
+			expectedUsage: `Usage:
+  root child [-F file | -D dir]... profile [flags]
+  root child --remove profile [flags]
+  root child --list [flags]
+
+Flags:
+  -D, --dir string    dir
+  -F, --file string   file
+      --list          list
+      --remove        remove
+`,
+			testCmd: func(newOut io.Writer) *zulu.Command {
+				root, child := createCmd()
+				child.Flags().String("file", "", "file", zflag.OptShorthand('F'))
+				child.Flags().String("dir", "", "dir", zflag.OptShorthand('D'))
+				child.Flags().Bool("remove", false, "remove")
+				child.Flags().Bool("list", false, "list")
+				child.AddUsageForm("child [-F file | -D dir]... profile")
+				child.AddUsageForm("child --remove profile")
+				child.AddUsageForm("child --list")
+				root.SetOut(newOut)
+				return child
+			},
+		},
 		{
 			name: "full test",
 			expectedUsage: `Usage:
@@ -2249,6 +3807,38 @@ func TestFind(t *testing.T) {
 	}
 }
 
+func TestSetColumnWidths(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:               "root",
+		CompletionOptions: zulu.CompletionOptions{DisableDefaultCmd: true},
+		RunE:              noopRun,
+	}
+	rootCmd.AddCommand(
+		&zulu.Command{Use: "a", Short: "short a", RunE: noopRun},
+		&zulu.Command{Use: "abc", Short: "short abc", RunE: noopRun},
+	)
+
+	output, err := executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, output, "\n  a           short a\n  abc         short abc\n")
+
+	rootCmd.SetColumnWidths(0, 0, 5)
+	output, err = executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, output, "\n  a     short a\n  abc   short abc\n")
+
+	// The columns are widened to fit the longest name, here "help"
+	rootCmd.SetColumnWidths(0, 0, 2)
+	output, err = executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, output, "\n  a    short a\n  abc  short abc\n")
+
+	padding := rootCmd.Commands()[0].Padding()
+	testutil.AssertEqual(t, 25, padding.Usage)
+	testutil.AssertEqual(t, 11, padding.CommandPath)
+	testutil.AssertEqual(t, 4, padding.Name)
+}
+
 // adapted from https://github.com/spf13/cobra/pull/1632/files#diff-4c08781a1c6c69898cdd3a21c0c759d846fc32148e6b5aaf70ad2db146e9f145R2165
 //
 //nolint:lll // can't cut it up
@@ -13,11 +13,11 @@ import (
 
 func runMouseTrap(command *Command) {
 	if MousetrapHelpText != "" && mousetrap.StartedByExplorer() {
-		c.Print(MousetrapHelpText)
+		command.Print(MousetrapHelpText)
 		if MousetrapDisplayDuration > 0 {
 			time.Sleep(MousetrapDisplayDuration)
 		} else {
-			c.Println("Press return to continue...")
+			command.Println("Press return to continue...")
 			fmt.Scanln()
 		}
 		os.Exit(1)
@@ -0,0 +1,132 @@
+package zulu
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CompletionCacheEnvVar is the environment variable which disables the
+// completion cache for all programs when set to "0".
+const CompletionCacheEnvVar = "ZULU_COMPLETION_CACHE"
+
+// completionCacheEntry is a cached result of a completion function.
+type completionCacheEntry struct {
+	ToComplete  string             `json:"toComplete"`
+	Completions []string           `json:"completions"`
+	Directive   ShellCompDirective `json:"directive"`
+	Expires     time.Time          `json:"expires"`
+}
+
+// completionCachePath returns the path of the file holding the completion cache
+// of the program. As the shells run the program for each completion request, the
+// cache must be persisted between them.
+func (c *Command) completionCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "zulu", c.Root().Name()+"-completions.json"), nil
+}
+
+// ClearCompletionCache removes all the completions cached for the program
+// because of the CacheTTL completion option.
+func (c *Command) ClearCompletionCache() error {
+	path, err := c.completionCachePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// cachedCompletions returns the completions for toComplete from the cache entry
+// of key, if there is one which has not expired and which was created for a
+// prefix of toComplete. Otherwise, it calls completionFn and caches its result.
+func (c *Command) cachedCompletions(
+	key, toComplete string,
+	completionFn func() ([]string, ShellCompDirective),
+) ([]string, ShellCompDirective) {
+	ttl := c.Root().CompletionOptions.CacheTTL
+	if ttl <= 0 || os.Getenv(CompletionCacheEnvVar) == "0" {
+		return completionFn()
+	}
+
+	path, err := c.completionCachePath()
+	if err != nil {
+		CompLogger().Println("Completion cache disabled:", err)
+		return completionFn()
+	}
+
+	entries := map[string]completionCacheEntry{}
+	if data, err := os.ReadFile(path); err == nil {
+		// A corrupted cache is simply replaced.
+		_ = json.Unmarshal(data, &entries)
+	}
+
+	now := time.Now()
+	if entry, ok := entries[key]; ok && now.Before(entry.Expires) && strings.HasPrefix(toComplete, entry.ToComplete) {
+		CompLogger().Println("Using cached completions for", entry.ToComplete)
+		return filterCachedCompletions(entry.Completions, toComplete), entry.Directive
+	}
+
+	completions, directive := completionFn()
+
+	// Completions which aren't candidates for toComplete, or which the shell must
+	// not complete with a space, can't be reused for a longer toComplete.
+	uncacheable := ShellCompDirectiveError | ShellCompDirectiveNoSpace |
+		ShellCompDirectiveFilterFileExt | ShellCompDirectiveFilterDirs
+	if directive&uncacheable != 0 {
+		return completions, directive
+	}
+
+	for k, entry := range entries {
+		if !now.Before(entry.Expires) {
+			delete(entries, k)
+		}
+	}
+	entries[key] = completionCacheEntry{
+		ToComplete:  toComplete,
+		Completions: completions,
+		Directive:   directive,
+		Expires:     now.Add(ttl),
+	}
+
+	if err := writeCompletionCache(path, entries); err != nil {
+		CompLogger().Println("Failed to write the completion cache:", err)
+	}
+
+	return completions, directive
+}
+
+func writeCompletionCache(path string, entries map[string]completionCacheEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// filterCachedCompletions returns the completions which start with toComplete,
+// as well as the ActiveHelp messages.
+func filterCachedCompletions(completions []string, toComplete string) []string {
+	var filtered []string
+	for _, comp := range completions {
+		value, _, _ := strings.Cut(comp, "\t")
+		if isActiveHelp(comp) || strings.HasPrefix(value, toComplete) {
+			filtered = append(filtered, comp)
+		}
+	}
+	return filtered
+}
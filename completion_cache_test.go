@@ -0,0 +1,71 @@
+package zulu_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestCompletionCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	calls := 0
+	directive := zulu.ShellCompDirectiveNoFileComp
+	rootCmd := &zulu.Command{
+		Use: "root",
+		ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+			calls++
+			return []string{"apple\tA fruit", "apricot", "banana"}, directive
+		},
+		RunE: noopRun,
+	}
+	rootCmd.CompletionOptions.CacheTTL = time.Hour
+
+	complete := func(toComplete string) string {
+		t.Helper()
+		output, err := executeCommand(rootCmd, zulu.ShellCompRequestCmd, toComplete)
+		testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+		return output
+	}
+
+	complete("a")
+	testutil.AssertEqual(t, 1, calls)
+
+	// Typing more of the argument reuses the cached completions.
+	expected := strings.Join([]string{
+		"apple\tA fruit",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, complete("app"))
+	testutil.AssertEqual(t, 1, calls)
+
+	// Going back to a shorter argument calls the function again.
+	complete("")
+	testutil.AssertEqual(t, 2, calls)
+
+	testutil.AssertNil(t, rootCmd.ClearCompletionCache())
+	complete("b")
+	testutil.AssertEqual(t, 3, calls)
+
+	t.Setenv(zulu.CompletionCacheEnvVar, "0")
+	complete("ba")
+	testutil.AssertEqual(t, 4, calls)
+	t.Setenv(zulu.CompletionCacheEnvVar, "")
+
+	// Completions which must not be followed by a space are not cached.
+	testutil.AssertNil(t, rootCmd.ClearCompletionCache())
+	directive = zulu.ShellCompDirectiveNoSpace
+	complete("a")
+	complete("ap")
+	testutil.AssertEqual(t, 6, calls)
+
+	// Without a TTL, there is no caching.
+	directive = zulu.ShellCompDirectiveNoFileComp
+	rootCmd.CompletionOptions.CacheTTL = 0
+	complete("a")
+	complete("ap")
+	testutil.AssertEqual(t, 8, calls)
+}
@@ -6,8 +6,11 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2/internal/template"
@@ -24,6 +27,13 @@ const (
 
 type FlagCompletionFn func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective)
 
+// CompletionCommandFilterFn reports whether the subcommand cmd should be offered
+// when completing toComplete after args, see Command.SetCompletionCommandFilter.
+type CompletionCommandFilterFn func(cmd *Command, args []string, toComplete string) bool
+
+// CompletionHookFn is a hook receiving the results of a completion request, see Command.OnCompletion.
+type CompletionHookFn func(cmd *Command, toComplete string, results []string, directive ShellCompDirective)
+
 // flagCompletionFunctions contains a global map of flag completion functions.
 // Make sure to use flagCompletionMutex before you try to read and write from it.
 var flagCompletionFunctions = map[*zflag.Flag]FlagCompletionFn{}
@@ -31,6 +41,14 @@ var flagCompletionFunctions = map[*zflag.Flag]FlagCompletionFn{}
 // Lock for reading and writing from flagCompletionFunctions.
 var flagCompletionMutex = &sync.RWMutex{}
 
+// Lock serializing the completion requests of Complete, as computing completions
+// parses, and so changes, the flags of the command tree.
+var completionMutex = &sync.Mutex{}
+
+// CompDebugFileEnvVar is the environment variable holding the location of the file
+// to which the logs of CompLogger are appended.
+const CompDebugFileEnvVar = "ZULU_COMP_DEBUG_FILE"
+
 var logger *log.Logger
 
 // ShellCompDirective is a bit map representing the different behaviors the shell
@@ -74,10 +92,11 @@ const (
 	// be provided in file completion.
 	// For example:
 	//    return nil, ShellCompDirectiveFilterDirs
-	// To request directory names within another directory, the returned completions
-	// should specify a single directory name within which to search. For example,
+	// To request directory names within other directories, the returned completions
+	// should specify the directory names within which to search. For example,
 	// to complete directories within "themes/":
 	//    return []string{"themes"}, ShellCompDirectiveFilterDirs
+	// Multiple directories are supported by bash, zsh and fish.
 	// The BashCompSubdirsInDir annotation can be used to
 	// obtain the same behavior but only for flags. The function FlagOptDirname
 	// zflag option has been provided as a convenience.
@@ -118,6 +137,45 @@ type CompletionOptions struct {
 	DisableDescriptions bool
 	// HiddenDefaultCmd makes the default 'completion' command hidden
 	HiddenDefaultCmd bool
+	// SuggestEqualForValueFlags also completes the --flag= form of the flags
+	// which take a value, so that users can go straight to typing the value.
+	SuggestEqualForValueFlags bool
+	// CacheTTL enables caching the results of the completion functions for
+	// that duration, which is useful when they are slow, e.g. because they call a
+	// remote service. A cached result is reused while the user keeps typing the
+	// same argument. Zero disables the cache, as does setting the
+	// ZULU_COMPLETION_CACHE environment variable to "0".
+	CacheTTL time.Duration
+	// SortSubcommands controls whether subcommands are completed sorted by name
+	// or in the order they were added. If nil, EnableCommandSorting is used,
+	// so that completions follow the order of the help output.
+	SortSubcommands *bool
+	// CommandName is the name of the default completion command, which is
+	// "completion" if empty. It allows programs having their own 'completion'
+	// command to still provide the default one.
+	CommandName string
+	// DefaultShell is the shell used when the default 'completion' command is
+	// invoked without specifying one. If empty, the shell is detected from $SHELL.
+	DefaultShell string
+	// SuggestDefaults completes the default value of a flag, with the
+	// "(default)" description, when nothing else is completed for its value.
+	SuggestDefaults bool
+	// NoMatchDirective replaces ShellCompDirectiveDefault when a completion
+	// function returns no completions, e.g. ShellCompDirectiveNoFileComp so that
+	// the shell does not fall back to completing file names. It is looked up on
+	// the command being completed, then on its parents.
+	NoMatchDirective ShellCompDirective
+}
+
+// noMatchDirective returns the CompletionOptions.NoMatchDirective of the
+// command or of its closest parent setting one.
+func (c *Command) noMatchDirective() ShellCompDirective {
+	for p := c; p != nil; p = p.Parent() {
+		if p.CompletionOptions.NoMatchDirective != ShellCompDirectiveDefault {
+			return p.CompletionOptions.NoMatchDirective
+		}
+	}
+	return ShellCompDirectiveDefault
 }
 
 // NoFileCompletions can be used to disable file completion for commands that should
@@ -136,6 +194,114 @@ func FixedCompletions(choices []string, directive ShellCompDirective) FlagComple
 	}
 }
 
+// SwitchCompletions can be used to create a completion function which uses
+// ifTrue or ifFalse depending on the state of the command, e.g. the value of
+// another flag, as reported by predicate.
+func SwitchCompletions(
+	predicate func(cmd *Command, args []string) bool,
+	ifTrue, ifFalse FlagCompletionFn,
+) FlagCompletionFn {
+	return func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective) {
+		if predicate(cmd, args) {
+			return ifTrue(cmd, args, toComplete)
+		}
+		return ifFalse(cmd, args, toComplete)
+	}
+}
+
+// Completion is a completion choice with its description.
+type Completion struct {
+	Value       string
+	Description string
+}
+
+// CompletionsWithDesc can be used to create a completion function which always
+// returns the same choices, along with their description. Only the first line
+// of the descriptions is kept.
+func CompletionsWithDesc(choices []Completion, directive ShellCompDirective) FlagCompletionFn {
+	completions := make([]string, 0, len(choices))
+	for _, choice := range choices {
+		desc, _, _ := strings.Cut(choice.Description, "\n")
+		if desc = strings.TrimSpace(desc); desc != "" {
+			completions = append(completions, choice.Value+"\t"+desc)
+		} else {
+			completions = append(completions, choice.Value)
+		}
+	}
+	return FixedCompletions(completions, directive)
+}
+
+// PairCompletions can be used to create a completion function for arguments
+// which are pairs of a key and a value, separated by sep, such as KEY=VALUE or
+// KEY:VALUE. Until sep is typed, the keys are completed, followed by sep and
+// without a space. Then the values of the typed key are completed.
+func PairCompletions(sep rune, keys func() []string, values func(key string) []string) FlagCompletionFn {
+	return func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective) {
+		key, _, found := strings.Cut(toComplete, string(sep))
+		if !found {
+			var completions []string
+			for _, k := range keys() {
+				completions = append(completions, k+string(sep))
+			}
+			return completions, ShellCompDirectiveNoSpace | ShellCompDirectiveNoFileComp
+		}
+
+		var completions []string
+		for _, v := range values(key) {
+			completions = append(completions, key+string(sep)+v)
+		}
+		return completions, ShellCompDirectiveNoFileComp
+	}
+}
+
+// PathCompletions can be used to create a completion function for paths relative
+// to dir, instead of the working directory of the shell, such as the files of a
+// project. If extensions are given, only the files with one of them are completed.
+// Directories are completed with a trailing separator and without a space, to
+// continue with their content. Absolute paths, including those starting with a
+// drive letter on Windows, are completed as is.
+func PathCompletions(dir string, extensions ...string) FlagCompletionFn {
+	return func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective) {
+		typedDir, prefix := filepath.Split(toComplete)
+		entries, err := os.ReadDir(pathSearchDir(dir, typedDir))
+		if err != nil {
+			CompLogger().Println(err)
+			return nil, ShellCompDirectiveNoFileComp
+		}
+
+		var completions []string
+		directive := ShellCompDirectiveNoFileComp
+		for _, entry := range entries {
+			name := entry.Name()
+			// Hidden files are only completed when explicitly asked for.
+			if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+				continue
+			}
+
+			if entry.IsDir() {
+				completions = append(completions, typedDir+name+string(filepath.Separator))
+				directive |= ShellCompDirectiveNoSpace
+				continue
+			}
+
+			if len(extensions) > 0 && !slices.Contains(extensions, strings.TrimPrefix(filepath.Ext(name), ".")) {
+				continue
+			}
+			completions = append(completions, typedDir+name)
+		}
+		return completions, directive
+	}
+}
+
+// pathSearchDir returns the directory to read to complete the paths in typedDir,
+// relative to dir unless typedDir is absolute or has a volume name.
+func pathSearchDir(dir, typedDir string) string {
+	if filepath.IsAbs(typedDir) || filepath.VolumeName(typedDir) != "" {
+		return typedDir
+	}
+	return filepath.Join(dir, typedDir)
+}
+
 // ListDirectives returns a string listing the different directive enabled in the specified parameter.
 func (d ShellCompDirective) ListDirectives() string {
 	var directives []string
@@ -161,6 +327,92 @@ func (d ShellCompDirective) ListDirectives() string {
 	return strings.Join(directives, ", ")
 }
 
+// Complete returns the completions of the last of args, which is the command line
+// without the program name, along with the directive for the shell, as the shell
+// completion scripts request them from root. It is safe to call concurrently on
+// the same tree, e.g. from a completion server: the requests are served one at a
+// time, each starting from the default values of the flags if AutoResetFlagsOnSetArgs
+// is set on root.
+func Complete(root *Command, args []string) ([]string, ShellCompDirective, error) {
+	completionMutex.Lock()
+	defer completionMutex.Unlock()
+
+	// The arguments are cloned, as computing the completions may write to them.
+	args = slices.Clone(args)
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	root = root.Root()
+	root.InitDefaultHelpCmd()
+	root.InitDefaultCompletionCmd()
+	if err := root.resetParseState(root.AutoResetFlagsOnSetArgs); err != nil {
+		return []string{}, ShellCompDirectiveDefault, err
+	}
+
+	finalCmd, completions, directive, err := root.getCompletions(args)
+	finalCmd.runCompletionHooks(args[len(args)-1], completions, directive)
+	return completions, directive, err
+}
+
+// OnCompletion registers one or more hooks on the command to be executed with
+// the results of the completions requested for the command or one of its
+// children, before they are printed, e.g. for telemetry. The hooks receive a copy
+// of the results, so they can't change them, and should be fast.
+func (c *Command) OnCompletion(f ...CompletionHookFn) {
+	c.completionHooks = append(c.completionHooks, f...)
+}
+
+// runCompletionHooks executes the completion hooks of the command and its parents.
+func (c *Command) runCompletionHooks(toComplete string, results []string, directive ShellCompDirective) {
+	for p := c; p != nil; p = p.Parent() {
+		for _, hook := range p.completionHooks {
+			hook(c, toComplete, slices.Clone(results), directive)
+		}
+	}
+}
+
+// SetCompletionCommandFilter sets the function deciding which subcommands of the
+// command and its children are offered by shell completions, e.g. to only offer
+// the commands available in the mode set by a previous flag. It is called with
+// each candidate, the arguments before the one being completed and the argument
+// being completed. The hidden and deprecated commands are never offered, whatever
+// the filter returns. The filter of the closest command is used.
+func (c *Command) SetCompletionCommandFilter(f CompletionCommandFilterFn) {
+	c.cmdCompletionFilter = f
+}
+
+// completionCommandFilter returns the completion command filter of the command
+// or of its closest parent having one, or nil.
+func (c *Command) completionCommandFilter() CompletionCommandFilterFn {
+	for p := c; p != nil; p = p.Parent() {
+		if p.cmdCompletionFilter != nil {
+			return p.cmdCompletionFilter
+		}
+	}
+	return nil
+}
+
+// completionCommands returns the child commands in the order they should be
+// completed, according to the SortSubcommands completion option.
+func (c *Command) completionCommands() []*Command {
+	sortCommands := EnableCommandSorting
+	if sortSubcommands := c.Root().CompletionOptions.SortSubcommands; sortSubcommands != nil {
+		sortCommands = *sortSubcommands
+	}
+
+	if sortCommands {
+		return c.commandsByName()
+	}
+	return c.commands
+}
+
+// isShellCompRequestCmd reports whether cmd is the hidden command used by the
+// completion scripts to request completions, which is never completed itself.
+func isShellCompRequestCmd(cmd *Command) bool {
+	return cmd.Name() == ShellCompRequestCmd || cmd.Name() == ShellCompNoDescRequestCmd
+}
+
 // Adds a special hidden command that can be used to request custom completions.
 func (c *Command) initCompleteCmd(args []string) {
 	completeCmd := &Command{
@@ -181,9 +433,15 @@ func (c *Command) initCompleteCmd(args []string) {
 				// 1- There could be some valid completions even though there was an error
 				// 2- Even without completions, we need to print the directive
 			}
+			finalCmd.runCompletionHooks(args[len(args)-1], completions, directive)
 
 			noDescriptions := cmd.CalledAs() == ShellCompNoDescRequestCmd
+			noActiveHelp := activeHelpDisabled()
 			for _, comp := range completions {
+				if noActiveHelp && isActiveHelp(comp) {
+					continue
+				}
+
 				if noDescriptions {
 					// Remove any description that may be included following a tab character.
 					comp = strings.Split(comp, "\t")[0]
@@ -263,6 +521,11 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 	}
 	finalCmd.ctx = c.ctx
 
+	if finalCmd.isMinimal() {
+		// Minimal commands are not completed, neither are their flags and arguments.
+		return finalCmd, nil, ShellCompDirectiveNoFileComp, nil
+	}
+
 	// These flags are normally added when `execute()` is called on `finalCmd`,
 	// however, when doing completion, we don't call `finalCmd.execute()`.
 	// Let's add the --help and --version flag ourselves.
@@ -308,7 +571,9 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 
 	// Look for the --help or --version flags.  If they are present,
 	// there should be no further completions.
-	if helpOrVersionFlagPresent(finalCmd) {
+	// The same goes when completing the value of one of these flags, e.g.
+	// <program> --help=<TAB>, as they are boolean flags which take no value.
+	if helpOrVersionFlagPresent(finalCmd) || (flag != nil && isHelpOrVersionFlag(flag)) {
 		return finalCmd, []string{}, ShellCompDirectiveNoFileComp, nil
 	}
 
@@ -333,13 +598,9 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 			// Even though it is a mistake on the program's side, let's be nice when we can.
 		}
 
-		if subDir, present := flag.Annotations[BashCompSubdirsInDir]; present {
-			if len(subDir) == 1 {
-				// Directory completion from within a directory
-				return finalCmd, subDir, ShellCompDirectiveFilterDirs, nil
-			}
-			// Directory completion
-			return finalCmd, []string{}, ShellCompDirectiveFilterDirs, nil
+		if subDirs, present := flag.Annotations[BashCompSubdirsInDir]; present {
+			// Directory completion, from within the given directories if any
+			return finalCmd, append([]string{}, subDirs...), ShellCompDirectiveFilterDirs, nil
 		}
 	}
 
@@ -362,11 +623,14 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 
 		// If we have not found any required flags, only then can we show regular flags
 		if len(completions) == 0 {
+			suggestEqual := finalCmd.Root().CompletionOptions.SuggestEqualForValueFlags
 			doCompleteFlags := func(flag *zflag.Flag) {
-				if _, isSlice := flag.Value.(zflag.SliceValue); !flag.Changed || isSlice {
+				_, isSlice := flag.Value.(zflag.SliceValue)
+				if (!flag.Changed && !finalCmd.flagSetFromEnv(flag)) || (isSlice && !finalCmd.maxOccurrencesReached(flag)) {
 					// If the flag is not already present, or if it can be specified multiple times (Array or Slice)
-					// we suggest it as a completion
-					completions = append(completions, getFlagNameCompletions(flag, toComplete)...)
+					// and has not been specified its maximum number of times, we suggest it as a completion.
+					// A flag set through its environment variable is considered present.
+					completions = append(completions, getFlagNameCompletions(flag, toComplete, suggestEqual)...)
 				}
 			}
 
@@ -382,7 +646,7 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 		}
 
 		directive = ShellCompDirectiveNoFileComp
-		if len(completions) == 1 && strings.HasSuffix(completions[0], "=") {
+		if len(completions) == 1 && strings.HasSuffix(strings.SplitN(completions[0], "\t", 2)[0], "=") {
 			// If there is a single completion, the shell usually adds a space
 			// after the completion.  We don't want that if the flag ends with an =
 			directive = ShellCompDirectiveNoSpace
@@ -416,14 +680,36 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 				// We only complete sub-commands if:
 				// - there are no arguments on the command-line and
 				// - there are no local, non-persistent flags on the command-line or TraverseChildren is true
-				for _, subCmd := range finalCmd.Commands() {
+				filter := finalCmd.completionCommandFilter()
+				for _, subCmd := range finalCmd.completionCommands() {
+					if subCmd.Minimal || isShellCompRequestCmd(subCmd) {
+						continue
+					}
+					if filter != nil && !filter(subCmd, trimmedArgs, toComplete) {
+						continue
+					}
 					if subCmd.IsAvailableCommand() || subCmd == finalCmd.helpCommand {
-						if strings.HasPrefix(subCmd.Name(), toComplete) {
+						if commandNameHasPrefix(subCmd.Name(), toComplete) {
 							completions = append(completions, fmt.Sprintf("%s\t%s", subCmd.Name(), subCmd.Short))
+						} else if toComplete != "" && finalCmd.prefixMatching() {
+							// With prefix matching, an alias prefix also resolves to the command,
+							// so offer the matching aliases to show every candidate of an
+							// ambiguous prefix.
+							for _, alias := range subCmd.Aliases {
+								if commandNameHasPrefix(alias, toComplete) {
+									completions = append(completions, fmt.Sprintf("%s\t%s", alias, subCmd.Short))
+								}
+							}
 						}
 						directive = ShellCompDirectiveNoFileComp
 					}
 				}
+				for _, name := range finalCmd.externalCommands() {
+					if commandNameHasPrefix(name, toComplete) {
+						completions = append(completions, name)
+						directive = ShellCompDirectiveNoFileComp
+					}
+				}
 			}
 
 			// Complete required flags even without the '-' prefix
@@ -453,8 +739,11 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 				}
 
 				// If there are ValidArgs specified (even if they don't match), we stop completion.
-				// Only one of ValidArgs or ValidArgsFunction can be used for a single command.
-				return finalCmd, completions, directive, nil
+				// Only one of ValidArgs or ValidArgsFunction can be used for a single command,
+				// unless the command asked to merge them.
+				if !finalCmd.MergeValidArgs {
+					return finalCmd, completions, directive, nil
+				}
 			}
 
 			// Let the logic continue so as to add any ValidArgsFunction completions,
@@ -475,27 +764,73 @@ func (c *Command) getCompletions(args []string) (*Command, []string, ShellCompDi
 	if completionFn != nil {
 		// Go custom completion defined for this flag or command.
 		// Call the registered completion function to get the completions.
+		// The result can come from the cache, which is keyed on the command-line
+		// and the flag being completed, if any.
+		cacheKey := strings.Join(append([]string{finalCmd.CommandPath()}, trimmedArgs...), "\x00")
+		if flag != nil && flagCompletion {
+			cacheKey += "\x00--" + flag.Name
+		}
+
 		var comps []string
-		comps, directive = completionFn(finalCmd, finalArgs, toComplete)
+		comps, directive = finalCmd.cachedCompletions(cacheKey, toComplete, func() ([]string, ShellCompDirective) {
+			return completionFn(finalCmd, finalArgs, toComplete)
+		})
 		completions = append(completions, comps...)
+
+		if flag == nil && finalCmd.MergeValidArgs {
+			completions = dedupCompletions(completions)
+		}
+
+		if len(completions) == 0 && directive == ShellCompDirectiveDefault {
+			directive = finalCmd.noMatchDirective()
+		}
+	}
+
+	if len(completions) == 0 && flag != nil && flagCompletion && finalCmd.Root().CompletionOptions.SuggestDefaults {
+		if flag.DefValue != "" && flag.DefValue != "[]" && strings.HasPrefix(flag.DefValue, toComplete) {
+			completions = append(completions, flag.DefValue+"\t(default)")
+		}
 	}
 
 	return finalCmd, completions, directive, nil
 }
 
+// dedupCompletions removes the completions whose value was already completed,
+// keeping the first one.
+func dedupCompletions(completions []string) []string {
+	seen := make(map[string]bool, len(completions))
+	deduped := completions[:0]
+	for _, comp := range completions {
+		value, _, _ := strings.Cut(comp, "\t")
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		deduped = append(deduped, comp)
+	}
+	return deduped
+}
+
 func helpOrVersionFlagPresent(cmd *Command) bool {
 	if versionFlag := cmd.Flags().Lookup("version"); versionFlag != nil &&
-		len(versionFlag.Annotations[FlagSetByZuluAnnotation]) > 0 && versionFlag.Changed {
+		isHelpOrVersionFlag(versionFlag) && versionFlag.Changed {
 		return true
 	}
 	if helpFlag := cmd.Flags().Lookup("help"); helpFlag != nil &&
-		len(helpFlag.Annotations[FlagSetByZuluAnnotation]) > 0 && helpFlag.Changed {
+		isHelpOrVersionFlag(helpFlag) && helpFlag.Changed {
 		return true
 	}
 	return false
 }
 
-func getFlagNameCompletions(flag *zflag.Flag, toComplete string) []string {
+// isHelpOrVersionFlag returns true if flag is the --help or --version flag
+// added by zulu, rather than a flag of the same name created by the program.
+func isHelpOrVersionFlag(flag *zflag.Flag) bool {
+	return (flag.Name == "help" || flag.Name == "version") &&
+		len(flag.Annotations[FlagSetByZuluAnnotation]) > 0
+}
+
+func getFlagNameCompletions(flag *zflag.Flag, toComplete string, suggestEqual bool) []string {
 	if nonCompletableFlag(flag) {
 		return []string{}
 	}
@@ -509,15 +844,14 @@ func getFlagNameCompletions(flag *zflag.Flag, toComplete string) []string {
 		// Why suggest both long forms: --flag and --flag= ?
 		// This forces the user to *always* have to type either an = or a space after the flag name.
 		// Let's be nice and avoid making users have to do that.
-		// Since boolean flags and shortname flags don't show the = form, let's go that route and never show it.
+		// Since boolean flags and shortname flags don't show the = form, let's go that route and
+		// only show it when the SuggestEqualForValueFlags completion option asks for it.
 		// The = form will still work, we just won't suggest it.
 		// This also makes the list of suggested flags shorter as we avoid all the = forms.
-		//
-		// if len(flag.NoOptDefVal) == 0 {
-		// 	// Flag requires a value, so it can be suffixed with =
-		// 	flagName += "="
-		// 	completions = append(completions, fmt.Sprintf("%s\t%s", flagName, flag.Usage))
-		// }
+		if _, isBool := flag.Value.(zflag.BoolFlag); suggestEqual && !isBool {
+			// Flag requires a value, so it can be suffixed with =
+			completions = append(completions, fmt.Sprintf("%s=\t%s", flagName, flag.Usage))
+		}
 	}
 
 	flagName = fmt.Sprintf("-%c", flag.Shorthand)
@@ -530,11 +864,13 @@ func getFlagNameCompletions(flag *zflag.Flag, toComplete string) []string {
 
 func completeRequireFlags(finalCmd *Command, toComplete string) []string {
 	var completions []string
+	suggestEqual := finalCmd.Root().CompletionOptions.SuggestEqualForValueFlags
 
 	doCompleteRequiredFlags := func(flag *zflag.Flag) {
-		if flag.Required && !flag.Changed {
-			// If the flag is not already present, we suggest it as a completion
-			completions = append(completions, getFlagNameCompletions(flag, toComplete)...)
+		if flag.Required && !flag.Changed && !finalCmd.flagSetFromEnv(flag) {
+			// If the flag is not already present, either on the command-line
+			// or through its environment variable, we suggest it as a completion
+			completions = append(completions, getFlagNameCompletions(flag, toComplete, suggestEqual)...)
 		}
 	}
 
@@ -642,14 +978,17 @@ func checkIfFlagCompletion(finalCmd *Command, args []string, lastArg string) (*z
 // This function will do nothing if any of the following is true:
 // 1- the feature has been explicitly disabled by the program,
 // 2- c has no subcommands (to avoid creating one),
-// 3- c already has a 'completion' command provided by the program.
+// 3- c already has a 'completion' command provided by the program,
+// 4- c is not the root command,
+// 5- c is Minimal.
 func (c *Command) InitDefaultCompletionCmd() {
-	if c.CompletionOptions.DisableDefaultCmd || !c.HasSubCommands() {
+	if c.CompletionOptions.DisableDefaultCmd || !c.HasSubCommands() || c.HasParent() || c.Minimal {
 		return
 	}
 
+	cmdName := c.completionCmdName()
 	for _, cmd := range c.commands {
-		if cmd.Name() == compCmdName || cmd.HasAlias(compCmdName) {
+		if cmd.Name() == cmdName || cmd.HasAlias(cmdName) {
 			// A completion command is already available
 			return
 		}
@@ -658,7 +997,7 @@ func (c *Command) InitDefaultCompletionCmd() {
 	long, err := template.ParseFromFile(
 		tmplFS,
 		"templates/usage_completion_root.txt.gotmpl",
-		map[string]string{"CMDName": c.Root().Name()},
+		map[string]string{"CMDName": c.Root().Name(), "CompletionCmdName": cmdName},
 		templateFuncs,
 	)
 	if err != nil {
@@ -666,12 +1005,13 @@ func (c *Command) InitDefaultCompletionCmd() {
 	}
 
 	completionCmd := &Command{
-		Use:               compCmdName,
+		Use:               cmdName,
 		Short:             "Generate the autocompletion script for the specified shell",
 		Long:              long,
 		Args:              NoArgs,
 		ValidArgsFunction: NoFileCompletions(),
 		Hidden:            c.CompletionOptions.HiddenDefaultCmd,
+		Annotations:       map[string]string{CommandSetByZuluAnnotation: "true"},
 	}
 	c.AddCommand(completionCmd)
 
@@ -713,7 +1053,57 @@ func (c *Command) InitDefaultCompletionCmd() {
 		},
 	)
 
-	completionCmd.AddCommand(bash, zsh, fish, powershell)
+	nushell := c.createCompletionCommand(
+		"nushell",
+		"templates/usage_completion_nushell.txt.gotmpl",
+		&includeDescriptions,
+		func(cmd *Command, args []string) error {
+			return cmd.Root().GenNushellCompletion(out, includeDescriptions)
+		},
+	)
+
+	completionCmd.AddCommand(bash, zsh, fish, powershell, nushell)
+
+	// Only make the shell optional when there is a shell to fall back to,
+	// otherwise 'completion' keeps printing its help.
+	if shell := c.defaultCompletionShell(); shell != "" {
+		completionCmd.RunE = func(cmd *Command, args []string) error {
+			for _, shellCmd := range cmd.Commands() {
+				if shellCmd.Name() == shell {
+					return shellCmd.RunE(shellCmd, args)
+				}
+			}
+			return fmt.Errorf("unsupported shell %q for %q", shell, cmd.CommandPath())
+		}
+	}
+}
+
+// defaultCompletionShell returns the shell used by the default 'completion'
+// command when none is given, either CompletionOptions.DefaultShell or the
+// shell detected from $SHELL. It returns an empty string if neither is known.
+func (c *Command) defaultCompletionShell() string {
+	if c.CompletionOptions.DefaultShell != "" {
+		return c.CompletionOptions.DefaultShell
+	}
+
+	switch shell := filepath.Base(os.Getenv("SHELL")); shell {
+	case "bash", "zsh", "fish":
+		return shell
+	case "pwsh", "powershell":
+		return "powershell"
+	case "nu", "nushell":
+		return "nushell"
+	default:
+		return ""
+	}
+}
+
+// completionCmdName returns the name of the default completion command.
+func (c *Command) completionCmdName() string {
+	if name := c.Root().CompletionOptions.CommandName; name != "" {
+		return name
+	}
+	return compCmdName
 }
 
 func (c *Command) createCompletionCommand(
@@ -725,7 +1115,11 @@ func (c *Command) createCompletionCommand(
 	long, err := template.ParseFromFile(
 		tmplFS,
 		usageTemplate,
-		map[string]string{"CMDName": c.Root().Name()},
+		map[string]string{
+			"CMDName":           c.Root().Name(),
+			"CMDVarName":        completionVarName(c.Root().Name()),
+			"CompletionCmdName": c.completionCmdName(),
+		},
 		templateFuncs,
 	)
 	if err != nil {
@@ -739,6 +1133,7 @@ func (c *Command) createCompletionCommand(
 		Args:              NoArgs,
 		ValidArgsFunction: NoFileCompletions(),
 		RunE:              runFn,
+		Annotations:       map[string]string{CommandSetByZuluAnnotation: "true"},
 	}
 
 	haveDescriptionsFlag := !c.CompletionOptions.DisableDescriptionsFlag && !c.CompletionOptions.DisableDescriptions
@@ -757,7 +1152,16 @@ func (c *Command) createCompletionCommand(
 
 func findFlag(cmd *Command, name string) *zflag.Flag {
 	if len(name) != 1 {
-		return cmd.Flag(name)
+		flag := cmd.Flag(name)
+		if flag == nil && len(name) > 3 && strings.HasPrefix(name, "no-") {
+			// The --no-<flag> form of the boolean flags added with zflag.OptAddNegative
+			if bFlag := cmd.Flag(name[3:]); bFlag != nil && bFlag.AddNegative {
+				if _, isBool := bFlag.Value.(zflag.BoolFlag); isBool {
+					flag = bFlag
+				}
+			}
+		}
+		return flag
 	}
 
 	// First convert the short flag into a long flag
@@ -774,48 +1178,62 @@ func findFlag(cmd *Command, name string) *zflag.Flag {
 	return nil
 }
 
-// CompLogger gets or creates a logger that prints to stderr or the completion log file.
-// Such logs are only printed when the user has set the environment variable `BASH_COMP_DEBUG`
-// to true. The logs can be optionally output to a file by setting `BASH_COMP_DEBUG_FILE` to
-// a file location.
+// CompLogger gets or creates the logger used to debug completions. By default, the
+// logs are discarded. They are appended to a file when the environment variable
+// ZULU_COMP_DEBUG_FILE, or BASH_COMP_DEBUG_FILE which is also used by the
+// completion scripts, is set to its location. SetCompletionLogger overrides it.
 func CompLogger() *log.Logger {
-	//nolint:nestif // todo refactor later
 	if logger == nil {
-		var f io.Writer
-		debugFile := os.Getenv("BASH_COMP_DEBUG_FILE")
-		if debugFile == "" {
-			f = io.Discard
-		} else {
-			var err error
-			f, err = os.OpenFile(debugFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				log.Println(err)
-			}
-
-			if fc, ok := f.(io.WriteCloser); ok {
-				defer fc.Close()
-			}
-		}
-		logger = log.New(f, "completion: ", log.Flags())
+		logger = log.New(compDebugWriter(), "completion: ", log.Flags())
 	}
 
 	return logger
 }
 
+// SetCompletionLogger replaces the logger returned by CompLogger, e.g. to send the
+// logs of completions to another destination. Setting it to nil restores the default.
+func SetCompletionLogger(l *log.Logger) {
+	logger = l
+}
+
+// compDebugWriter opens the completion debug file for appending, if set.
+func compDebugWriter() io.Writer {
+	debugFile := os.Getenv(CompDebugFileEnvVar)
+	if debugFile == "" {
+		debugFile = os.Getenv("BASH_COMP_DEBUG_FILE")
+	}
+	if debugFile == "" {
+		return io.Discard
+	}
+
+	// The file is left open for the lifetime of the program.
+	f, err := os.OpenFile(debugFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Println(err)
+		return io.Discard
+	}
+	return f
+}
+
+// completionVarName returns name in a form usable in the names of the
+// functions and variables of the completion scripts.
+func completionVarName(name string) string {
+	name = strings.ReplaceAll(name, "-", "_")
+	name = strings.ReplaceAll(name, ":", "_")
+	return name
+}
+
 func genTemplateCompletion(buf io.Writer, templateFile string, name string, includeDesc bool) error {
 	compCmd := ShellCompRequestCmd
 	if !includeDesc {
 		compCmd = ShellCompNoDescRequestCmd
 	}
 
-	nameForVar := name
-	nameForVar = strings.ReplaceAll(nameForVar, "-", "_")
-	nameForVar = strings.ReplaceAll(nameForVar, ":", "_")
-
 	res, err := template.ParseFromFile(tmplFS, templateFile, map[string]any{
-		"CMDVarName":                      nameForVar,
+		"CMDVarName":                      completionVarName(name),
 		"CMDName":                         name,
 		"CompletionCommand":               compCmd,
+		"ActiveHelpMarker":                activeHelpMarker,
 		"ShellCompDirectiveError":         ShellCompDirectiveError,
 		"ShellCompDirectiveNoSpace":       ShellCompDirectiveNoSpace,
 		"ShellCompDirectiveNoFileComp":    ShellCompDirectiveNoFileComp,
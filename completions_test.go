@@ -3,7 +3,14 @@ package zulu_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/zulucmd/zflag/v2"
@@ -125,6 +132,156 @@ func TestCmdNameCompletionInGo(t *testing.T) {
 	testutil.AssertEqual(t, expected, output)
 }
 
+func TestCmdNameCompletionIndependentOfArgZero(t *testing.T) {
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+
+	expected := strings.Join([]string{
+		"child",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	for _, argZero := range []string{"root", "./root", "/usr/local/bin/root", `C:\bin\root.exe`, "/tmp/go-build/root.test"} {
+		t.Run(argZero, func(t *testing.T) {
+			rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+			rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+			os.Args = []string{argZero, zulu.ShellCompNoDescRequestCmd, "ch"}
+			output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "ch")
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertEqual(t, expected, output)
+
+			if strings.HasSuffix(argZero, ".test") {
+				// The args of test binaries are only used when set explicitly.
+				return
+			}
+
+			rootCmd = &zulu.Command{Use: "root", RunE: noopRun}
+			rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+			buf := new(bytes.Buffer)
+			rootCmd.SetOut(buf)
+			rootCmd.SetErr(buf)
+			err = rootCmd.Execute()
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertEqual(t, expected, buf.String())
+		})
+	}
+}
+
+func TestShellCompRequestCmdNotCompleted(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	// Requesting completions leaves the __complete command in the tree
+	_, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	found := false
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == zulu.ShellCompRequestCmd {
+			found = true
+			// Even if it is made visible, it is not completed
+			cmd.Hidden = false
+		}
+	}
+	testutil.AssertEqualf(t, true, found, "Expected the %s command to be in the tree", zulu.ShellCompRequestCmd)
+
+	for _, args := range [][]string{{""}, {"_"}, {"help", ""}, {"help", "_"}} {
+		output, err := executeCommand(rootCmd, append([]string{zulu.ShellCompNoDescRequestCmd}, args...)...)
+		testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+		testutil.AssertNotContains(t, output, zulu.ShellCompRequestCmd)
+		testutil.AssertNotContains(t, output, zulu.ShellCompNoDescRequestCmd)
+	}
+
+	comps, _, err := zulu.Complete(rootCmd, []string{""})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertNotContains(t, strings.Join(comps, " "), zulu.ShellCompRequestCmd)
+}
+
+func TestCompletionAmbiguousPrefix(t *testing.T) {
+	prefixMatching := true
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun, PrefixMatching: &prefixMatching}
+	rootCmd.AddCommand(
+		&zulu.Command{Use: "times", Short: "times short", RunE: noopRun},
+		&zulu.Command{Use: "timer", Short: "timer short", RunE: noopRun},
+		&zulu.Command{Use: "echo", Aliases: []string{"tell"}, Short: "echo short", RunE: noopRun},
+	)
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompRequestCmd, "ti")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	expected := strings.Join([]string{
+		"timer\ttimer short",
+		"times\ttimes short",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// Aliases resolve under prefix matching, so they are part of the ambiguity
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "t")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	expected = strings.Join([]string{
+		"tell\techo short",
+		"timer\ttimer short",
+		"times\ttimes short",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	prefixMatching = false
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "t")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	expected = strings.Join([]string{
+		"timer\ttimer short",
+		"times\ttimes short",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestSetCompletionCommandFilter(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	rootCmd.PersistentFlags().String("mode", "stable", "mode")
+	rootCmd.AddCommand(
+		&zulu.Command{Use: "run", Short: "run short", RunE: noopRun},
+		&zulu.Command{Use: "try", Short: "try short", RunE: noopRun, Annotations: map[string]string{"mode": "experimental"}},
+		&zulu.Command{Use: "trial", Short: "trial short", RunE: noopRun, Hidden: true, Annotations: map[string]string{"mode": "experimental"}},
+	)
+	rootCmd.SetCompletionCommandFilter(func(cmd *zulu.Command, args []string, toComplete string) bool {
+		mode, _ := cmd.Parent().Flags().GetString("mode")
+		return cmd.Annotations["mode"] == "" || cmd.Annotations["mode"] == mode
+	})
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	expected := strings.Join([]string{
+		"completion",
+		"help",
+		"run",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// The filter also applies to the commands completed for the help command
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "help", "t")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	expected = strings.Join([]string{
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// Hidden commands are never offered, whatever the filter returns
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "--mode", "experimental", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	expected = strings.Join([]string{
+		"completion",
+		"help",
+		"run",
+		"try",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
+
 func TestNoCmdNameCompletionInGo(t *testing.T) {
 	rootCmd := &zulu.Command{
 		Use:  "root",
@@ -310,6 +467,58 @@ func TestValidArgsCompletionInGo(t *testing.T) {
 	testutil.AssertEqual(t, expected, output)
 }
 
+func TestNoMatchDirective(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:  "root",
+		RunE: noopRun,
+		CompletionOptions: zulu.CompletionOptions{
+			NoMatchDirective: zulu.ShellCompDirectiveNoFileComp,
+		},
+	}
+	childCmd := &zulu.Command{
+		Use:  "child",
+		RunE: noopRun,
+		ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+			if toComplete == "n" {
+				return nil, zulu.ShellCompDirectiveNoSpace
+			}
+			if toComplete == "o" {
+				return []string{"one"}, zulu.ShellCompDirectiveDefault
+			}
+			return nil, zulu.ShellCompDirectiveDefault
+		},
+	}
+	rootCmd.AddCommand(childCmd)
+
+	// An empty result with the default directive is upgraded
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "child", "x")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// An empty result with another directive is left alone
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "child", "n")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		":2",
+		"Completion ended with directive: ShellCompDirectiveNoSpace", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// A non-empty result is left alone
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "child", "o")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"one",
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
+
 func TestValidArgsAndCmdCompletionInGo(t *testing.T) {
 	rootCmd := &zulu.Command{
 		Use:       "root",
@@ -994,7 +1203,7 @@ func TestFlagDirFilterCompletionInGo(t *testing.T) {
 		zulu.FlagOptDirname("themes"),
 	)
 
-	// Multiple directory specification get ignored
+	// Multiple directory specification
 	rootCmd.Flags().String(
 		"manydir",
 		"",
@@ -1067,6 +1276,8 @@ func TestFlagDirFilterCompletionInGo(t *testing.T) {
 	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
 
 	expected = strings.Join([]string{
+		"themes",
+		"colors",
 		":16",
 		"Completion ended with directive: ShellCompDirectiveFilterDirs", ""}, "\n")
 
@@ -1242,6 +1453,43 @@ func TestValidArgsFuncChildCmds(t *testing.T) {
 	testutil.AssertEqual(t, expected, output)
 }
 
+func TestValidArgsFuncChildCmdsAfterPersistentFlags(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	rootCmd.PersistentFlags().String("str", "", "a string", zflag.OptShorthand('s'))
+	rootCmd.PersistentFlags().Bool("bool", false, "a bool", zflag.OptShorthand('b'))
+	subCmd := &zulu.Command{
+		Use:               "sub",
+		ValidArgsFunction: validArgsFunc,
+		RunE:              noopRun,
+	}
+	rootCmd.AddCommand(subCmd, &zulu.Command{Use: "other", RunE: noopRun})
+
+	expected := strings.Join([]string{
+		"one",
+		"two",
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+
+	tests := [][]string{
+		{"--str", "value", "sub", ""},
+		{"--str=value", "sub", ""},
+		{"-s", "value", "sub", ""},
+		{"-b", "sub", ""},
+		{"--bool", "--str", "value", "sub", ""},
+		// The flag value has the same name as a subcommand
+		{"--str", "other", "sub", ""},
+		{"--str", "sub", "sub", ""},
+		{"sub", "--str", "value", ""},
+		{"-bs", "value", "sub", ""},
+	}
+
+	for _, args := range tests {
+		output, err := executeCommand(rootCmd, append([]string{zulu.ShellCompNoDescRequestCmd}, args...)...)
+		testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+		testutil.AssertEqualf(t, expected, output, "Unexpected completions for %q", args)
+	}
+}
+
 func TestValidArgsFuncAliases(t *testing.T) {
 	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
 	child := &zulu.Command{
@@ -1319,6 +1567,61 @@ func TestCompleteCmdInZshScript(t *testing.T) {
 	testutil.AssertNotContains(t, output, zulu.ShellCompNoDescRequestCmd)
 }
 
+func TestCompletionDependsOnPersistentFlag(t *testing.T) {
+	regions := map[string][]string{
+		"dev":  {"dev-east", "dev-west"},
+		"prod": {"prod-east"},
+	}
+	completeRegions := func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+		return regions[cmd.Flag("profile").Value.String()], zulu.ShellCompDirectiveNoFileComp
+	}
+
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected []string
+	}{
+		{
+			desc:     "default profile",
+			args:     []string{"child", "--region", ""},
+			expected: regions["dev"],
+		},
+		{
+			desc:     "profile before the subcommand",
+			args:     []string{"--profile", "prod", "child", "--region", ""},
+			expected: regions["prod"],
+		},
+		{
+			desc:     "profile after the subcommand",
+			args:     []string{"child", "--profile=prod", "--region", ""},
+			expected: regions["prod"],
+		},
+		{
+			desc:     "argument completion",
+			args:     []string{"--profile", "prod", "child", ""},
+			expected: regions["prod"],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+			rootCmd.PersistentFlags().String("profile", "dev", "profile to use")
+			childCmd := &zulu.Command{Use: "child", RunE: noopRun, ValidArgsFunction: completeRegions}
+			childCmd.Flags().String("region", "", "region to use", zulu.FlagOptCompletionFunc(completeRegions))
+			rootCmd.AddCommand(childCmd)
+
+			output, err := executeCommand(rootCmd, append([]string{zulu.ShellCompNoDescRequestCmd}, tc.args...)...)
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+			expected := strings.Join(append(slices.Clone(tc.expected),
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""), "\n")
+			testutil.AssertEqual(t, expected, output)
+		})
+	}
+}
+
 func TestFlagCompletionInGo(t *testing.T) {
 	rootCmd := &zulu.Command{
 		Use:  "root",
@@ -1853,6 +2156,42 @@ func TestValidArgsNotValidArgsFunc(t *testing.T) {
 	testutil.AssertEqual(t, expected, output)
 }
 
+func TestMergeValidArgs(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:            "root",
+		ValidArgs:      []string{"one\tThe first", "two"},
+		MergeValidArgs: true,
+		ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+			return []string{"two\tAgain", "three"}, zulu.ShellCompDirectiveNoFileComp
+		},
+		RunE: noopRun,
+	}
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"one\tThe first",
+		"two",
+		"three",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+
+	// ValidArgs are only for the first argument
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "one", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"two\tAgain",
+		"three",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+}
+
 func TestArgAliasesCompletionInGo(t *testing.T) {
 	rootCmd := &zulu.Command{
 		Use:        "root",
@@ -1957,6 +2296,24 @@ func TestCompleteHelp(t *testing.T) {
 	testutil.AssertEqual(t, expected, output)
 }
 
+func TestDefaultCompletionCmdOnlyOnRoot(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	grandchildCmd := &zulu.Command{Use: "grandchild", RunE: noopRun}
+	childCmd.AddCommand(grandchildCmd)
+	rootCmd.AddCommand(childCmd)
+
+	childCmd.InitDefaultCompletionCmd()
+	for _, cmd := range childCmd.Commands() {
+		testutil.AssertNotEqualf(
+			t,
+			zulu.CompCmdName,
+			cmd.Name(),
+			"Should not have a 'completion' command on a sub-command",
+		)
+	}
+}
+
 func removeCompCmd(rootCmd *zulu.Command) {
 	// Remove completion command for the next test
 	for _, cmd := range rootCmd.Commands() {
@@ -2046,7 +2403,7 @@ func TestDefaultCompletionCmd(t *testing.T) {
 	var compCmd *zulu.Command
 	// Test that the --no-descriptions flag is present on all shells
 	testutil.AssertNil(t, rootCmd.Execute())
-	for _, shell := range []string{"bash", "fish", "powershell", "zsh"} {
+	for _, shell := range []string{"bash", "fish", "nushell", "powershell", "zsh"} {
 		compCmd, _, err = rootCmd.Find([]string{zulu.CompCmdName, shell})
 		testutil.AssertNilf(t, err, "Unexpected error")
 		flag := compCmd.Flags().Lookup(zulu.CompCmdNoDescFlagName)
@@ -2058,7 +2415,7 @@ func TestDefaultCompletionCmd(t *testing.T) {
 	// Test that the '--no-descriptions' flag can be disabled
 	rootCmd.CompletionOptions.DisableDescriptionsFlag = true
 	testutil.AssertNil(t, rootCmd.Execute())
-	for _, shell := range []string{"fish", "zsh", "bash", "powershell"} {
+	for _, shell := range []string{"fish", "zsh", "bash", "powershell", "nushell"} {
 		compCmd, _, err = rootCmd.Find([]string{zulu.CompCmdName, shell})
 		testutil.AssertNilf(t, err, "Unexpected error")
 		flag := compCmd.Flags().Lookup(zulu.CompCmdNoDescFlagName)
@@ -2072,7 +2429,7 @@ func TestDefaultCompletionCmd(t *testing.T) {
 	// Test that the '--no-descriptions' flag is disabled when descriptions are disabled
 	rootCmd.CompletionOptions.DisableDescriptions = true
 	testutil.AssertNil(t, rootCmd.Execute())
-	for _, shell := range []string{"fish", "zsh", "bash", "powershell"} {
+	for _, shell := range []string{"fish", "zsh", "bash", "powershell", "nushell"} {
 		compCmd, _, err = rootCmd.Find([]string{zulu.CompCmdName, shell})
 		testutil.AssertNilf(t, err, "Unexpected error")
 		flag := compCmd.Flags().Lookup(zulu.CompCmdNoDescFlagName)
@@ -2100,6 +2457,110 @@ func TestDefaultCompletionCmd(t *testing.T) {
 	removeCompCmd(rootCmd)
 }
 
+func TestCompleteCompletionDescriptionsFlag(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	// The flag is offered
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, zulu.CompCmdName, "zsh", "-")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"--" + zulu.CompCmdNoDescFlagName,
+		"--help",
+		"-h",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// Nothing is completed after the flag, in either of its forms, and no files either
+	expected = strings.Join([]string{
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	for _, flagArg := range []string{"--" + zulu.CompCmdNoDescFlagName, "--no-" + zulu.CompCmdNoDescFlagName} {
+		output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, zulu.CompCmdName, "zsh", flagArg, "")
+		testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+		testutil.AssertEqual(t, expected, output)
+	}
+}
+
+func TestDefaultCompletionCmdName(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	rootCmd.CompletionOptions.CommandName = "shell-completion"
+
+	// The program has its own completion command
+	var called bool
+	rootCmd.AddCommand(&zulu.Command{
+		Use: zulu.CompCmdName,
+		RunE: func(cmd *zulu.Command, args []string) error {
+			called = true
+			return nil
+		},
+	})
+
+	output, err := executeCommand(rootCmd, "shell-completion", "bash")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, output, "bash completion for root")
+
+	output, err = executeCommand(rootCmd, "shell-completion", "bash", "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, output, "source <(root shell-completion bash)")
+
+	_, err = executeCommand(rootCmd, zulu.CompCmdName)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqualf(t, true, called, "the program's completion command should be called")
+}
+
+func TestDefaultCompletionCmdDefaultShell(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		defaultShell string
+		envShell     string
+		args         []string
+		expected     string
+	}{
+		{
+			desc:     "explicit shell",
+			envShell: "/bin/zsh",
+			args:     []string{zulu.CompCmdName, "fish"},
+			expected: "complete -c root",
+		},
+		{
+			desc:         "configured default",
+			defaultShell: "fish",
+			envShell:     "/bin/zsh",
+			args:         []string{zulu.CompCmdName},
+			expected:     "complete -c root",
+		},
+		{
+			desc:     "autodetect from $SHELL",
+			envShell: "/usr/bin/zsh",
+			args:     []string{zulu.CompCmdName},
+			expected: "zsh completion for root",
+		},
+		{
+			desc:     "no default shell",
+			envShell: "/bin/tcsh",
+			args:     []string{zulu.CompCmdName},
+			expected: "Generate the autocompletion script for the specified shell",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv("SHELL", tc.envShell)
+
+			rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+			rootCmd.AddCommand(&zulu.Command{Use: "sub", RunE: noopRun})
+			rootCmd.CompletionOptions.DefaultShell = tc.defaultShell
+
+			output, err := executeCommand(rootCmd, tc.args...)
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertContains(t, output, tc.expected)
+		})
+	}
+}
+
 func TestCompleteCompletion(t *testing.T) {
 	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
 	subCmd := &zulu.Command{
@@ -2115,6 +2576,7 @@ func TestCompleteCompletion(t *testing.T) {
 	expected := strings.Join([]string{
 		"bash",
 		"fish",
+		"nushell",
 		"powershell",
 		"zsh",
 		":4",
@@ -2359,20 +2821,591 @@ func TestFixedCompletions(t *testing.T) {
 	testutil.AssertEqual(t, expected, output)
 }
 
-func TestCompletionForGroupedFlags(t *testing.T) {
-	getCmd := func() *zulu.Command {
-		rootCmd := &zulu.Command{
-			Use:  "root",
-			RunE: noopRun,
-		}
-		childCmd := &zulu.Command{
-			Use: "child",
-			ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
-				return []string{"subArg"}, zulu.ShellCompDirectiveNoFileComp
-			},
-			RunE: noopRun,
-		}
-		rootCmd.AddCommand(childCmd)
+func TestSwitchCompletions(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().Bool("raw", false, "raw output")
+	rootCmd.Flags().String("format", "", "output format", zulu.FlagOptCompletionFunc(zulu.SwitchCompletions(
+		func(cmd *zulu.Command, args []string) bool {
+			raw, _ := cmd.Flags().GetBool("raw")
+			return raw
+		},
+		zulu.FixedCompletions([]string{"bin", "hex"}, zulu.ShellCompDirectiveNoFileComp),
+		zulu.FixedCompletions([]string{"json", "yaml"}, zulu.ShellCompDirectiveNoFileComp),
+	)))
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "--format", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"json",
+		"yaml",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "--raw", "--format", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"bin",
+		"hex",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestSuggestDefaults(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().Int("count", 10, "number of items")
+	rootCmd.Flags().String("name", "", "name of the item")
+	rootCmd.Flags().String("format", "json", "output format",
+		zulu.FlagOptCompletionFunc(zulu.FixedCompletions([]string{"json", "yaml"}, zulu.ShellCompDirectiveNoFileComp)))
+
+	// Without the option, nothing is completed
+	output, err := executeCommand(rootCmd, zulu.ShellCompRequestCmd, "--count", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// The default value is completed
+	rootCmd.CompletionOptions.SuggestDefaults = true
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "--count", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"10\t(default)",
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// But not if it does not match
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "--count", "2")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// Nor if it is empty
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "--name", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, expected, output)
+
+	// Nor if other values are completed
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "--format", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"json",
+		"yaml",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestRegisterFlagCompletionFunc(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().String("profile", "", "profile to use")
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().String("region", "", "region to use")
+	rootCmd.AddCommand(childCmd)
+
+	err := childCmd.RegisterFlagCompletionFunc("region", zulu.FixedCompletions([]string{"us", "eu"}, zulu.ShellCompDirectiveNoFileComp))
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	err = rootCmd.RegisterFlagCompletionFunc("profile", zulu.FixedCompletions([]string{"dev", "prod"}, zulu.ShellCompDirectiveNoFileComp))
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	err = childCmd.RegisterFlagCompletionFunc("region", zulu.NoFileCompletions())
+	testutil.AssertErrf(t, err, "expected an error when registering a flag twice")
+	err = childCmd.RegisterFlagCompletionFunc("zone", zulu.NoFileCompletions())
+	testutil.AssertErrf(t, err, "expected an error for an unknown flag")
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "child", "--region", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"us",
+		"eu",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "child", "--profile", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"dev",
+		"prod",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestGetFlagCompletionFunc(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().String("output", "", "output format",
+		zulu.FlagOptCompletionFunc(zulu.FixedCompletions([]string{"json", "yaml"}, zulu.ShellCompDirectiveNoFileComp)))
+	rootCmd.Flags().String("name", "", "name")
+
+	_, exists := rootCmd.GetFlagCompletionFunc("name")
+	testutil.AssertEqualf(t, false, exists, "expected no completion function for flag 'name'")
+	_, exists = rootCmd.GetFlagCompletionFunc("unknown")
+	testutil.AssertEqualf(t, false, exists, "expected no completion function for an unknown flag")
+
+	completionFn, exists := rootCmd.GetFlagCompletionFunc("output")
+	testutil.AssertEqualf(t, true, exists, "expected a completion function for flag 'output'")
+
+	// Wrap the existing function to only keep the completions with the prefix.
+	err := rootCmd.RegisterFlagCompletionFunc("name", func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+		comps, directive := completionFn(cmd, args, toComplete)
+		var filtered []string
+		for _, comp := range comps {
+			if strings.HasPrefix(comp, toComplete) {
+				filtered = append(filtered, comp)
+			}
+		}
+		return filtered, directive
+	})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "--name", "y")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"yaml",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestCompletionSortSubcommands(t *testing.T) {
+	sortSubcommands, keepOrder := true, false
+	testCases := []struct {
+		desc                 string
+		enableCommandSorting bool
+		sortSubcommands      *bool
+		expected             []string
+	}{
+		{
+			desc:                 "inherit sorting",
+			enableCommandSorting: true,
+			expected:             []string{"afirst", "help", "middle", "zlast"},
+		},
+		{
+			desc:                 "inherit no sorting",
+			enableCommandSorting: false,
+			expected:             []string{"middle", "zlast", "afirst", "help"},
+		},
+		{
+			desc:                 "sort without help sorting",
+			enableCommandSorting: false,
+			sortSubcommands:      &sortSubcommands,
+			expected:             []string{"afirst", "help", "middle", "zlast"},
+		},
+		{
+			desc:                 "keep order with help sorting",
+			enableCommandSorting: true,
+			sortSubcommands:      &keepOrder,
+			expected:             []string{"middle", "zlast", "afirst", "help"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			zulu.EnableCommandSorting = tc.enableCommandSorting
+			defer func() { zulu.EnableCommandSorting = true }()
+
+			rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+			rootCmd.CompletionOptions.DisableDefaultCmd = true
+			rootCmd.CompletionOptions.SortSubcommands = tc.sortSubcommands
+			for _, name := range []string{"middle", "zlast", "afirst"} {
+				rootCmd.AddCommand(&zulu.Command{Use: name, RunE: noopRun})
+			}
+
+			// Sort the commands for the help output beforehand, which must not
+			// change the order of the completions.
+			rootCmd.Commands()
+
+			output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "")
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+			expected := strings.Join(append(tc.expected,
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""), "\n")
+
+			testutil.AssertEqual(t, expected, output)
+		})
+	}
+}
+
+func TestSuggestEqualForValueFlags(t *testing.T) {
+	getCmd := func(suggestEqual bool) *zulu.Command {
+		rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+		rootCmd.CompletionOptions.SuggestEqualForValueFlags = suggestEqual
+		rootCmd.Flags().String("name", "", "the name")
+		rootCmd.Flags().Bool("nice", false, "be nice")
+		return rootCmd
+	}
+
+	output, err := executeCommand(getCmd(false), zulu.ShellCompRequestCmd, "--n")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"--name\tthe name",
+		"--nice\tbe nice",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+
+	output, err = executeCommand(getCmd(true), zulu.ShellCompRequestCmd, "--n")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"--name\tthe name",
+		"--name=\tthe name",
+		"--nice\tbe nice",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestCompletionsWithDesc(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use: "root",
+		ValidArgsFunction: zulu.CompletionsWithDesc([]zulu.Completion{
+			{Value: "one", Description: "The first"},
+			{Value: "two", Description: "The second\nwith more details"},
+			{Value: "three"},
+		}, zulu.ShellCompDirectiveNoFileComp),
+		RunE: noopRun,
+	}
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"one\tThe first",
+		"two\tThe second",
+		"three",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestOnCompletion(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	childCmd := &zulu.Command{
+		Use: "child",
+		ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+			return []string{"one\tThe first", "two"}, zulu.ShellCompDirectiveNoFileComp
+		},
+		RunE: noopRun,
+	}
+	rootCmd.AddCommand(childCmd)
+
+	var calls int
+	var gotCmd *zulu.Command
+	var gotToComplete string
+	var gotResults []string
+	var gotDirective zulu.ShellCompDirective
+	rootCmd.OnCompletion(func(cmd *zulu.Command, toComplete string, results []string, directive zulu.ShellCompDirective) {
+		calls++
+		gotCmd, gotToComplete, gotResults, gotDirective = cmd, toComplete, slices.Clone(results), directive
+		results[0] = "modified"
+	})
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompRequestCmd, "child", "t")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	testutil.AssertEqualf(t, 1, calls, "Expected the hook to be called once")
+	testutil.AssertEqualf(t, childCmd, gotCmd, "Expected the hook to receive the completed command")
+	testutil.AssertEqual(t, "t", gotToComplete)
+	testutil.AssertEqual(t, "one\tThe first\ntwo", strings.Join(gotResults, "\n"))
+	testutil.AssertEqual(t, zulu.ShellCompDirectiveNoFileComp, gotDirective)
+
+	expected := strings.Join([]string{
+		"one\tThe first",
+		"two",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestCompLoggerDebugFile(t *testing.T) {
+	debugFile := filepath.Join(t.TempDir(), "completion.log")
+	t.Setenv(zulu.CompDebugFileEnvVar, debugFile)
+	zulu.SetCompletionLogger(nil)
+	t.Cleanup(func() { zulu.SetCompletionLogger(nil) })
+
+	zulu.CompLogger().Println("first")
+	zulu.CompLogger().Println("second")
+
+	content, err := os.ReadFile(debugFile)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertContains(t, string(content), "completion: ")
+	testutil.AssertContains(t, string(content), "first\n")
+	testutil.AssertContains(t, string(content), "second\n")
+
+	buf := new(bytes.Buffer)
+	zulu.SetCompletionLogger(log.New(buf, "", 0))
+	zulu.CompLogger().Println("third")
+	testutil.AssertEqual(t, "third\n", buf.String())
+}
+
+func TestPathCompletions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.yaml", ".hidden.json", filepath.Join("sub", "c.json")} {
+		testutil.AssertNil(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		testutil.AssertNil(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+	sep := string(filepath.Separator)
+
+	testCases := []struct {
+		desc       string
+		extensions []string
+		toComplete string
+		expected   []string
+	}{
+		{
+			desc:     "all entries",
+			expected: []string{"a.json", "b.yaml", "sub" + sep, ":6"},
+		},
+		{
+			desc:       "filtered by extension",
+			extensions: []string{"json"},
+			expected:   []string{"a.json", "sub" + sep, ":6"},
+		},
+		{
+			desc:       "hidden files when asked for",
+			toComplete: ".",
+			expected:   []string{".hidden.json", ":4"},
+		},
+		{
+			desc:       "entries of a sub directory",
+			toComplete: "sub" + sep,
+			expected:   []string{"sub" + sep + "c.json", ":4"},
+		},
+		{
+			desc:       "absolute path",
+			toComplete: filepath.Join(dir, "sub") + sep + "c",
+			expected:   []string{filepath.Join(dir, "sub", "c.json"), ":4"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			rootCmd := &zulu.Command{
+				Use:               "root",
+				ValidArgsFunction: zulu.PathCompletions(dir, tc.extensions...),
+				RunE:              noopRun,
+			}
+
+			output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, tc.toComplete)
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertEqual(t, strings.Join(tc.expected, "\n"), strings.Join(strings.Split(output, "\n")[:len(tc.expected)], "\n"))
+		})
+	}
+}
+
+func TestPathSearchDirWindows(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("Windows paths are only handled on Windows")
+	}
+
+	testutil.AssertEqual(t, `C:\project\sub`, zulu.PathSearchDir(`C:\project`, `sub\`))
+	testutil.AssertEqual(t, `C:\project\sub`, zulu.PathSearchDir(`C:\project`, `sub/`))
+	testutil.AssertEqual(t, `D:\data\`, zulu.PathSearchDir(`C:\project`, `D:\data\`))
+	testutil.AssertEqual(t, `D:`, zulu.PathSearchDir(`C:\project`, `D:`))
+	testutil.AssertEqual(t, `\\server\share\`, zulu.PathSearchDir(`C:\project`, `\\server\share\`))
+}
+
+func TestPathSearchDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix paths are not absolute on Windows")
+	}
+
+	testutil.AssertEqual(t, "/project", zulu.PathSearchDir("/project", ""))
+	testutil.AssertEqual(t, "/project/sub", zulu.PathSearchDir("/project", "sub/"))
+	testutil.AssertEqual(t, "/data/", zulu.PathSearchDir("/project", "/data/"))
+}
+
+func TestCompleteKeepsFlagsWithoutAutoReset(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().String("name", "default", "")
+	err := rootCmd.Flags().Set("name", "set")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	_, _, err = zulu.Complete(rootCmd, []string{""})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "set", rootCmd.Flags().Lookup("name").Value.String())
+
+	rootCmd.AutoResetFlagsOnSetArgs = true
+	_, _, err = zulu.Complete(rootCmd, []string{""})
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "default", rootCmd.Flags().Lookup("name").Value.String())
+}
+
+func TestCompleteConcurrently(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun, AutoResetFlagsOnSetArgs: true}
+	rootCmd.PersistentFlags().String("region", "", "region", zulu.FlagOptCompletionFunc(
+		zulu.FixedCompletions([]string{"eu", "us"}, zulu.ShellCompDirectiveNoFileComp),
+	))
+	childCmd := &zulu.Command{
+		Use:   "child",
+		Short: "child",
+		RunE:  noopRun,
+		ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+			region, _ := cmd.Flags().GetString("region")
+			return []string{"arg-" + region}, zulu.ShellCompDirectiveNoFileComp
+		},
+	}
+	childCmd.Flags().Bool("force", false, "force")
+	rootCmd.AddCommand(childCmd)
+
+	requests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{""}, "child\tchild\ncompletion\tGenerate the autocompletion script for the specified shell\nhelp\tHelp about any command:4"},
+		{[]string{"--region", ""}, "eu\nus:4"},
+		{[]string{"child", "--region", "eu", ""}, "arg-eu:4"},
+		{[]string{"child", ""}, "arg-:4"},
+		{[]string{"child", "--f"}, "--force\tforce:4"},
+	}
+
+	var wg sync.WaitGroup
+	failures := make(chan string, 50*len(requests))
+	for i := 0; i < 50; i++ {
+		for _, request := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				completions, directive, err := zulu.Complete(rootCmd, request.args)
+				actual := fmt.Sprintf("%s:%d", strings.Join(completions, "\n"), directive)
+				if err != nil || actual != request.expected {
+					failures <- fmt.Sprintf("%v: got %q (err: %v), expected %q", request.args, actual, err, request.expected)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(failures)
+
+	for failure := range failures {
+		t.Error(failure)
+	}
+}
+
+func TestPairCompletions(t *testing.T) {
+	keys := func() []string { return []string{"env", "tier"} }
+	values := func(key string) []string {
+		if key == "env" {
+			return []string{"dev", "prod"}
+		}
+		return nil
+	}
+
+	testCases := []struct {
+		desc     string
+		sep      rune
+		arg      string
+		expected []string
+	}{
+		{
+			desc: "keys with =",
+			sep:  '=',
+			arg:  "e",
+			expected: []string{
+				"env=",
+				"tier=",
+				":6",
+				"Completion ended with directive: ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp",
+			},
+		},
+		{
+			desc: "values with =",
+			sep:  '=',
+			arg:  "env=",
+			expected: []string{
+				"env=dev",
+				"env=prod",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp",
+			},
+		},
+		{
+			desc: "keys with :",
+			sep:  ':',
+			arg:  "",
+			expected: []string{
+				"env:",
+				"tier:",
+				":6",
+				"Completion ended with directive: ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp",
+			},
+		},
+		{
+			desc: "values with :",
+			sep:  ':',
+			arg:  "env:p",
+			expected: []string{
+				"env:dev",
+				"env:prod",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp",
+			},
+		},
+		{
+			desc: "unknown key",
+			sep:  ':',
+			arg:  "size:",
+			expected: []string{
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			rootCmd := &zulu.Command{
+				Use:               "root",
+				ValidArgsFunction: zulu.PairCompletions(tc.sep, keys, values),
+				RunE:              noopRun,
+			}
+
+			output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, tc.arg)
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertEqual(t, strings.Join(append(tc.expected, ""), "\n"), output)
+		})
+	}
+}
+
+func TestCompletionForGroupedFlags(t *testing.T) {
+	getCmd := func() *zulu.Command {
+		rootCmd := &zulu.Command{
+			Use:  "root",
+			RunE: noopRun,
+		}
+		childCmd := &zulu.Command{
+			Use: "child",
+			ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+				return []string{"subArg"}, zulu.ShellCompDirectiveNoFileComp
+			},
+			RunE: noopRun,
+		}
+		rootCmd.AddCommand(childCmd)
 
 		rootCmd.PersistentFlags().Int("ingroup1", -1, "ingroup1")
 		rootCmd.PersistentFlags().String("ingroup2", "", "ingroup2")
@@ -2455,6 +3488,160 @@ func TestCompletionForGroupedFlags(t *testing.T) {
 	}
 }
 
+func TestCompletionForOneRequiredGroupFlags(t *testing.T) {
+	getCmd := func() *zulu.Command {
+		rootCmd := &zulu.Command{
+			Use:  "root",
+			RunE: noopRun,
+		}
+		childCmd := &zulu.Command{
+			Use: "child",
+			ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+				return []string{"subArg"}, zulu.ShellCompDirectiveNoFileComp
+			},
+			RunE: noopRun,
+		}
+		rootCmd.AddCommand(childCmd)
+
+		rootCmd.PersistentFlags().Int("ingroup1", -1, "ingroup1")
+		rootCmd.PersistentFlags().String("ingroup2", "", "ingroup2")
+
+		childCmd.Flags().Bool("ingroup3", false, "ingroup3")
+		childCmd.Flags().Bool("nogroup", false, "nogroup")
+
+		// Add flags to a group
+		childCmd.MarkFlagsOneRequired("ingroup1", "ingroup2", "ingroup3")
+
+		return rootCmd
+	}
+
+	// Each test case uses a unique command from the function above.
+	testcases := []struct {
+		desc           string
+		args           []string
+		expectedOutput string
+	}{
+		{
+			desc: "flags in group suggested without - prefix when none is set",
+			args: []string{"child", ""},
+			expectedOutput: strings.Join([]string{
+				"--ingroup1",
+				"--ingroup2",
+				"--ingroup3",
+				"subArg",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "only flags in group suggested with - prefix when none is set",
+			args: []string{"child", "-"},
+			expectedOutput: strings.Join([]string{
+				"--ingroup1",
+				"--ingroup2",
+				"--ingroup3",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "when flag in group present, other flags in group not suggested without - prefix",
+			args: []string{"child", "--ingroup2", "value", ""},
+			expectedOutput: strings.Join([]string{
+				"subArg",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "group ignored if some flags not applicable",
+			args: []string{""},
+			expectedOutput: strings.Join([]string{
+				"child",
+				"completion",
+				"help",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := getCmd()
+			args := []string{zulu.ShellCompNoDescRequestCmd}
+			args = append(args, tc.args...)
+			output, err := executeCommand(c, args...)
+			testutil.AssertNilf(t, err, "Unexpected error %q", err)
+			testutil.AssertEqual(t, tc.expectedOutput, output)
+		})
+	}
+}
+
+func TestCompletionForMutuallyExclusiveAndOneRequiredFlags(t *testing.T) {
+	getCmd := func() *zulu.Command {
+		rootCmd := &zulu.Command{
+			Use:  "root",
+			RunE: noopRun,
+		}
+		childCmd := &zulu.Command{
+			Use: "child",
+			ValidArgsFunction: func(cmd *zulu.Command, args []string, toComplete string) ([]string, zulu.ShellCompDirective) {
+				return []string{"subArg"}, zulu.ShellCompDirectiveNoFileComp
+			},
+			RunE: noopRun,
+		}
+		rootCmd.AddCommand(childCmd)
+
+		rootCmd.PersistentFlags().Int("ingroup1", -1, "ingroup1")
+		rootCmd.PersistentFlags().String("ingroup2", "", "ingroup2")
+
+		childCmd.Flags().Bool("ingroup3", false, "ingroup3")
+		childCmd.Flags().Bool("nogroup", false, "nogroup")
+
+		// Add flags to a group
+		childCmd.MarkFlagsMutuallyExclusiveAndOneRequired("ingroup1", "ingroup2", "ingroup3")
+
+		return rootCmd
+	}
+
+	// Each test case uses a unique command from the function above.
+	testcases := []struct {
+		desc           string
+		args           []string
+		expectedOutput string
+	}{
+		{
+			desc: "all flags in group suggested when none is set",
+			args: []string{"child", ""},
+			expectedOutput: strings.Join([]string{
+				"--ingroup1",
+				"--ingroup2",
+				"--ingroup3",
+				"subArg",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "no flags in group suggested when one is set",
+			args: []string{"child", "--ingroup2", "value", "-"},
+			expectedOutput: strings.Join([]string{
+				"--help",
+				"-h",
+				"--nogroup",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := getCmd()
+			args := []string{zulu.ShellCompNoDescRequestCmd}
+			args = append(args, tc.args...)
+			output, err := executeCommand(c, args...)
+			testutil.AssertNilf(t, err, "Unexpected error %q", err)
+			testutil.AssertEqual(t, tc.expectedOutput, output)
+		})
+	}
+}
+
 func TestCompletionForMutuallyExclusiveFlags(t *testing.T) {
 	getCmd := func() *zulu.Command {
 		rootCmd := &zulu.Command{
@@ -2695,6 +3882,27 @@ func TestCompletionZuluFlags(t *testing.T) {
 				":4",
 				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
 		},
+		{
+			desc: "no flag completion after --help flag",
+			args: []string{"--help", "-"},
+			expectedOutput: strings.Join([]string{
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "no value completion for --help flag",
+			args: []string{"--help="},
+			expectedOutput: strings.Join([]string{
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "no completion after --version flag followed by other flags",
+			args: []string{"child", "--version", "--bool", ""},
+			expectedOutput: strings.Join([]string{
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
 		{
 			desc: "completion after --help flag when created by program",
 			args: []string{"child2", "--help", ""},
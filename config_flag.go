@@ -0,0 +1,101 @@
+package zulu
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ConfigLoaderFn loads the file at path, returning the flag values it contains
+// keyed by flag name.
+type ConfigLoaderFn func(path string) (map[string]string, error)
+
+// ConfigFlagOpt configures the behaviour of the flag added by EnableConfigFlag.
+type ConfigFlagOpt func(*configFlag)
+
+// ConfigOptErrorOnUnknownKeys makes keys which do not match any flag of the
+// executed command an error, instead of ignoring them.
+func ConfigOptErrorOnUnknownKeys() ConfigFlagOpt {
+	return func(cf *configFlag) {
+		cf.errorOnUnknownKeys = true
+	}
+}
+
+type configFlag struct {
+	name               string
+	loader             ConfigLoaderFn
+	errorOnUnknownKeys bool
+	// values are the values loaded from the file of the last parsed command.
+	values map[string]string
+}
+
+// EnableConfigFlag adds a persistent string flag called name, pointing to a
+// file whose values are used for any flag not set on the command line.
+// The file is a flag source of the command, as added by BindFlags: after the
+// flags are parsed, loader is called with the path given to the flag, and
+// each loaded value is applied to the flag with the same name, unless that
+// flag has been changed on the command line, through its environment variable
+// or by a source added before. The flags taking a value from the file are
+// marked as changed.
+func (c *Command) EnableConfigFlag(name string, loader ConfigLoaderFn, opts ...ConfigFlagOpt) {
+	cf := &configFlag{name: name, loader: loader}
+	for _, opt := range opts {
+		opt(cf)
+	}
+
+	c.PersistentFlags().String(name, "", "config file to read flag values from")
+	c.configFlag = cf
+	c.BindFlags(cf.lookup)
+}
+
+// loadConfigFlags loads the files given to the config flags of c and its
+// parents, before their values are looked up as flag sources.
+func (c *Command) loadConfigFlags() error {
+	for p := c; p != nil; p = p.Parent() {
+		if p.configFlag == nil {
+			continue
+		}
+
+		if err := p.configFlag.load(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cf *configFlag) load(c *Command) error {
+	cf.values = nil
+
+	flag := c.Flags().Lookup(cf.name)
+	if flag == nil || flag.Value.String() == "" {
+		return nil
+	}
+
+	path := flag.Value.String()
+	values, err := cf.loader(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config file %q: %w", path, err)
+	}
+
+	if cf.errorOnUnknownKeys {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if c.Flags().Lookup(key) == nil {
+				return fmt.Errorf("unknown key %q in config file %q", key, path)
+			}
+		}
+	}
+
+	cf.values = values
+	return nil
+}
+
+func (cf *configFlag) lookup(flagName string) (string, bool) {
+	value, ok := cf.values[flagName]
+	return value, ok
+}
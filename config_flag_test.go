@@ -0,0 +1,101 @@
+package zulu_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestEnableConfigFlag(t *testing.T) {
+	loader := func(path string) (map[string]string, error) {
+		if path != "config.yaml" {
+			return nil, errors.New("no such file")
+		}
+		return map[string]string{"str": "from-config", "int": "42", "unknown": "value"}, nil
+	}
+
+	testCases := []struct {
+		desc        string
+		opts        []zulu.ConfigFlagOpt
+		args        []string
+		required    bool
+		expectedStr string
+		expectedInt int
+		expectedErr string
+	}{
+		{
+			desc:        "no config file",
+			args:        []string{"child"},
+			expectedStr: "default",
+			expectedInt: 1,
+		},
+		{
+			desc:        "values from config file",
+			args:        []string{"child", "--config", "config.yaml"},
+			expectedStr: "from-config",
+			expectedInt: 42,
+		},
+		{
+			desc:        "required flag from config file",
+			args:        []string{"child", "--config", "config.yaml"},
+			required:    true,
+			expectedStr: "from-config",
+			expectedInt: 42,
+		},
+		{
+			desc:        "required flag missing",
+			args:        []string{"child"},
+			required:    true,
+			expectedErr: `required flag(s) "--str" not set`,
+		},
+		{
+			desc:        "command line takes precedence",
+			args:        []string{"child", "--config", "config.yaml", "--str", "from-cli"},
+			expectedStr: "from-cli",
+			expectedInt: 42,
+		},
+		{
+			desc:        "unknown keys are an error",
+			opts:        []zulu.ConfigFlagOpt{zulu.ConfigOptErrorOnUnknownKeys()},
+			args:        []string{"child", "--config", "config.yaml"},
+			expectedErr: `unknown key "unknown" in config file "config.yaml"`,
+		},
+		{
+			desc:        "loader error",
+			args:        []string{"child", "--config", "missing.yaml"},
+			expectedErr: `failed to load config file "missing.yaml": no such file`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var str string
+			var i int
+			rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+			childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+			if tc.required {
+				childCmd.Flags().StringVar(&str, "str", "default", "", zflag.OptRequired())
+			} else {
+				childCmd.Flags().StringVar(&str, "str", "default", "")
+			}
+			childCmd.Flags().IntVar(&i, "int", 1, "")
+			rootCmd.AddCommand(childCmd)
+			rootCmd.EnableConfigFlag("config", loader, tc.opts...)
+
+			_, err := executeCommand(rootCmd, tc.args...)
+			if tc.expectedErr != "" {
+				testutil.AssertErrf(t, err, "Expected an error")
+				testutil.AssertEqual(t, tc.expectedErr, err.Error())
+				return
+			}
+
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertEqual(t, tc.expectedStr, str)
+			testutil.AssertEqual(t, tc.expectedInt, i)
+			testutil.AssertEqual(t, tc.expectedStr != "default", childCmd.Flags().Changed("str"))
+		})
+	}
+}
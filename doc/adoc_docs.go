@@ -13,8 +13,8 @@ import (
 	"github.com/zulucmd/zulu/v2"
 )
 
-func printOptionsAdoc(buf *bytes.Buffer, cmd *zulu.Command) error {
-	flags := cmd.NonInheritedFlags()
+func printOptionsAdoc(buf *bytes.Buffer, cmd *zulu.Command, opts GenOptions) error {
+	flags := documentedFlagSet(cmd.NonInheritedFlags(), opts)
 	flags.SetOutput(buf)
 	if flags.HasAvailableFlags() {
 		buf.WriteString("=== Options\n\n....\n")
@@ -22,7 +22,7 @@ func printOptionsAdoc(buf *bytes.Buffer, cmd *zulu.Command) error {
 		buf.WriteString("....\n\n")
 	}
 
-	parentFlags := cmd.InheritedFlags()
+	parentFlags := documentedFlagSet(cmd.InheritedFlags(), opts)
 	parentFlags.SetOutput(buf)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("=== Options inherited from parent commands\n\n....\n")
@@ -32,13 +32,36 @@ func printOptionsAdoc(buf *bytes.Buffer, cmd *zulu.Command) error {
 	return nil
 }
 
+func printFlagGroupsAdoc(buf *bytes.Buffer, cmd *zulu.Command) {
+	relations := cmd.FlagGroupRelations()
+	if len(relations) == 0 {
+		return
+	}
+
+	buf.WriteString("=== Flag constraints\n\n")
+	for _, relation := range relations {
+		buf.WriteString("* " + relation.String() + "\n")
+	}
+	buf.WriteString("\n")
+}
+
 // GenAsciidoc creates Asciidoc output.
 func GenAsciidoc(cmd *zulu.Command, w io.Writer) error {
 	return GenAsciidocCustom(cmd, w, func(s string) string { return s })
 }
 
+// GenAsciidocWithOptions is the same as GenAsciidoc, but opts can add the
+// deprecated and hidden flags to the options.
+func GenAsciidocWithOptions(cmd *zulu.Command, w io.Writer, opts GenOptions) error {
+	return genAsciidoc(cmd, w, func(s string) string { return s }, opts)
+}
+
 // GenAsciidocCustom creates custom AsciiDoc output.
 func GenAsciidocCustom(cmd *zulu.Command, w io.Writer, linkHandler func(string) string) error {
+	return genAsciidoc(cmd, w, linkHandler, GenOptions{})
+}
+
+func genAsciidoc(cmd *zulu.Command, w io.Writer, linkHandler func(string) string, opts GenOptions) error {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
 	cmd.InitDefaultCompletionCmd()
@@ -57,14 +80,15 @@ func GenAsciidocCustom(cmd *zulu.Command, w io.Writer, linkHandler func(string)
 		buf.WriteString(fmt.Sprintf("....\n%s\n....\n\n", cmd.UseLine()))
 	}
 
-	if len(cmd.Example) > 0 {
+	if cmd.HasExample() {
 		buf.WriteString("=== Examples\n\n")
-		buf.WriteString(fmt.Sprintf("....\n%s\n....\n\n", cmd.Example))
+		buf.WriteString(fmt.Sprintf("....\n%s\n....\n\n", cmd.ExampleText()))
 	}
 
-	if err := printOptionsAdoc(buf, cmd); err != nil {
+	if err := printOptionsAdoc(buf, cmd, opts); err != nil {
 		return err
 	}
+	printFlagGroupsAdoc(buf, cmd)
 	if hasSeeAlso(cmd) {
 		buf.WriteString("=== SEE ALSO\n\n")
 		if cmd.HasParent() {
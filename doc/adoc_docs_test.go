@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2"
 	"github.com/zulucmd/zulu/v2/doc"
 	"github.com/zulucmd/zulu/v2/internal/testutil"
@@ -46,6 +47,28 @@ func TestGenAsciidocWithNoLongOrSynopsis(t *testing.T) {
 	testutil.AssertNotContains(t, output, "### Synopsis")
 }
 
+func TestGenAsciidocFlagGroups(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().Bool("json", false, "json output")
+	cmd.Flags().Bool("yaml", false, "yaml output")
+	cmd.Flags().Bool("secret", false, "secret", zflag.OptHidden())
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml", "secret")
+	cmd.MarkFlagsRequiredTogether("json", "secret")
+
+	buf := new(bytes.Buffer)
+	if err := doc.GenAsciidoc(cmd, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	testutil.AssertContains(t, output, `=== Flag constraints
+
+* The flags --json, --yaml are mutually exclusive.
+
+`)
+	testutil.AssertNotContains(t, output, "secret")
+}
+
 func TestGenAsciidocNoHiddenParents(t *testing.T) {
 	rootCmd, echoCmd, echoSubCmd, _, deprecatedCmd, _, _ := getTestCmds()
 	// We generate on subcommand so we have both subcommands and parents.
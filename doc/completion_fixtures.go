@@ -0,0 +1,88 @@
+package doc
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// completionFixture is a completion request and its results.
+type completionFixture struct {
+	Args        []string `yaml:"args,flow"`
+	ToComplete  string   `yaml:"to_complete"`
+	Completions []string `yaml:"completions,omitempty"`
+	Directive   int      `yaml:"directive"`
+}
+
+// GenCompletionFixtures requests the completions of cmd and its descendants,
+// and writes the requests and their results to w as yaml, to be checked into
+// the tests of the program as a golden file. For each command, it records the
+// completions of its arguments and subcommands, of its flag names, and of the
+// values of its local non-boolean flags, as returned by the __complete command.
+func GenCompletionFixtures(cmd *zulu.Command, w io.Writer) error {
+	var fixtures []completionFixture
+	for _, request := range completionRequests(cmd) {
+		fixture, err := requestCompletions(cmd.Root(), request)
+		if err != nil {
+			return err
+		}
+		fixtures = append(fixtures, fixture)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(fixtures); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// completionRequests returns the completion requests of cmd and its
+// documented descendants.
+func completionRequests(cmd *zulu.Command) []completionFixture {
+	var path []string
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		path = append([]string{c.Name()}, path...)
+	}
+
+	requests := []completionFixture{
+		{Args: path, ToComplete: ""},
+		{Args: path, ToComplete: "-"},
+	}
+	cmd.NonInheritedFlags().VisitAll(func(flag *zflag.Flag) {
+		if _, isBool := flag.Value.(zflag.BoolFlag); isBool || flag.Hidden || flag.Deprecated != "" {
+			return
+		}
+		args := append(append([]string{}, path...), "--"+flag.Name)
+		requests = append(requests, completionFixture{Args: args, ToComplete: ""})
+	})
+
+	for _, c := range cmd.Commands() {
+		if isDocumented(c) {
+			requests = append(requests, completionRequests(c)...)
+		}
+	}
+	return requests
+}
+
+// requestCompletions requests the completions of request from root, as the
+// __complete command does, and fills in the results.
+func requestCompletions(root *zulu.Command, request completionFixture) (completionFixture, error) {
+	args := append(slices.Clone(request.Args), request.ToComplete)
+	completions, directive, err := zulu.Complete(root, args)
+	if err != nil {
+		return request, err
+	}
+
+	for _, comp := range completions {
+		// Only the first line is printed by the __complete command, trimmed.
+		comp, _, _ = strings.Cut(comp, "\n")
+		request.Completions = append(request.Completions, strings.TrimSpace(comp))
+	}
+	request.Directive = int(directive)
+	return request, nil
+}
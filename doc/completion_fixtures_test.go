@@ -0,0 +1,41 @@
+package doc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/doc"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestGenCompletionFixtures(t *testing.T) {
+	rootCmd, _, _, _, _, _, _ := getTestCmds()
+
+	buf := new(bytes.Buffer)
+	err := doc.GenCompletionFixtures(rootCmd, buf)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	output := buf.String()
+
+	for _, args := range []string{"[]", "[echo]", "[echo, echosub]", "[echo, times]"} {
+		testutil.AssertContains(t, output, "- args: "+args+"\n  to_complete: \"\"\n")
+		testutil.AssertContains(t, output, "- args: "+args+"\n  to_complete: '-'\n")
+	}
+	for _, args := range []string{"[--rootflag]", "[--strtwo]", "[echo, --intone]", "[echo, --strone]", "[echo, times, --inttwo]"} {
+		testutil.AssertContains(t, output, "- args: "+args+"\n")
+	}
+	testutil.AssertContains(t, output, "\"echosub\\tsecond sub command for echo\"")
+	testutil.AssertContains(t, output, "\"--intone\\thelp message for flag intone\"")
+	testutil.AssertNotContains(t, output, "deprecated")
+}
+
+func TestGenCompletionFixturesKeepsFlags(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	rootCmd.Flags().String("name", "default", "")
+	err := rootCmd.Flags().Set("name", "set")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	err = doc.GenCompletionFixtures(rootCmd, new(bytes.Buffer))
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "set", rootCmd.Flags().Lookup("name").Value.String())
+}
@@ -0,0 +1,180 @@
+package doc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/template"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+)
+
+// GenOptions controls which flags are documented, see GenerateCustomWithOptions
+// and the WithOptions variants of the other generators.
+type GenOptions struct {
+	// IncludeDeprecated includes the deprecated flags.
+	IncludeDeprecated bool
+	// IncludeHidden includes the hidden flags.
+	IncludeHidden bool
+}
+
+// GenerateCustom renders the template file templateName for cmd, and writes
+// the result to w. The template is executed with a map holding the standard
+// documentation data:
+//
+//   - Command: the *zulu.Command itself
+//   - Name: the full command path
+//   - Short, Long and Example, the latter holding the Examples as text if set
+//   - Examples: the structured examples, as zulu.CommandExample
+//   - UseLine: the usage line, empty if the command is not runnable
+//   - Flags and InheritedFlags: the visible local and inherited flags
+//   - FlagGroups: the relationships between flags created with the MarkFlags
+//     methods of the command, as zulu.FlagGroupRelation
+//   - Parent: the parent command, nil for the root command
+//   - Children: the documented subcommands, sorted by name
+//   - AutoGenTag: whether the "Auto generated" line should be added
+//
+// extraData is merged into that map, overriding the standard keys, and
+// extraFuncs is added to the template functions. The is_boolean function,
+// which returns true for boolean flags, is always available.
+func GenerateCustom(
+	cmd *zulu.Command,
+	w io.Writer,
+	templateName string,
+	extraData map[string]any,
+	extraFuncs template.FuncMap,
+) error {
+	return GenerateCustomWithOptions(cmd, w, templateName, extraData, extraFuncs, GenOptions{})
+}
+
+// GenerateCustomWithOptions is the same as GenerateCustom, but opts can add the
+// deprecated and hidden flags to Flags and InheritedFlags. The template can
+// tell them apart through their Deprecated and Hidden fields, e.g. to add a
+// "(deprecated)" marker.
+func GenerateCustomWithOptions(
+	cmd *zulu.Command,
+	w io.Writer,
+	templateName string,
+	extraData map[string]any,
+	extraFuncs template.FuncMap,
+	opts GenOptions,
+) error {
+	text, err := os.ReadFile(templateName)
+	if err != nil {
+		return fmt.Errorf("failed to read template %q: %w", templateName, err)
+	}
+
+	funcs := template.FuncMap{
+		"is_boolean": isBooleanFlag,
+	}
+	for name, fn := range extraFuncs {
+		funcs[name] = fn
+	}
+
+	tmpl, err := template.New(templateName).Funcs(funcs).Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("failed to parse template %q: %w", templateName, err)
+	}
+
+	data := genTemplateData(cmd, opts)
+	for key, value := range extraData {
+		data[key] = value
+	}
+
+	return tmpl.Execute(w, data)
+}
+
+func genTemplateData(cmd *zulu.Command, opts GenOptions) map[string]any {
+	cmd.InitDefaultHelpCmd()
+	cmd.InitDefaultHelpFlag()
+	cmd.InitDefaultCompletionCmd()
+
+	if cmd.HasParent() {
+		cmd.VisitParents(func(c *zulu.Command) {
+			if c.DisableAutoGenTag {
+				cmd.DisableAutoGenTag = c.DisableAutoGenTag
+			}
+		})
+	}
+
+	useLine := ""
+	if cmd.Runnable() {
+		useLine = cmd.UseLine()
+	}
+
+	var children []*zulu.Command
+	for _, child := range cmd.Commands() {
+		if isDocumented(child) {
+			children = append(children, child)
+		}
+	}
+	sort.Sort(byName(children))
+
+	return map[string]any{
+		"Command":        cmd,
+		"Name":           cmd.CommandPath(),
+		"Short":          cmd.Short,
+		"Long":           cmd.Long,
+		"Example":        cmd.ExampleText(),
+		"Examples":       cmd.Examples,
+		"UseLine":        useLine,
+		"Flags":          documentedFlags(cmd.NonInheritedFlags(), opts),
+		"InheritedFlags": documentedFlags(cmd.InheritedFlags(), opts),
+		"FlagGroups":     cmd.FlagGroupRelations(),
+		"Parent":         cmd.Parent(),
+		"Children":       children,
+		"AutoGenTag":     !cmd.DisableAutoGenTag,
+	}
+}
+
+// documentedFlags returns the flags of fs which are neither hidden nor
+// deprecated, unless opts includes them.
+func documentedFlags(fs *zflag.FlagSet, opts GenOptions) []*zflag.Flag {
+	var flags []*zflag.Flag
+	fs.VisitAll(func(flag *zflag.Flag) {
+		switch {
+		// Deprecated flags are hidden as well, so check them first.
+		case len(flag.Deprecated) > 0:
+			if !opts.IncludeDeprecated {
+				return
+			}
+		case flag.Hidden:
+			if !opts.IncludeHidden {
+				return
+			}
+		}
+		flags = append(flags, flag)
+	})
+	return flags
+}
+
+// documentedFlagSet returns a flag set holding the flags of fs documentedFlags
+// returns for opts, for the generators printing flag sets. The hidden flags it
+// holds are made visible, the deprecated ones keeping their Deprecated field.
+func documentedFlagSet(fs *zflag.FlagSet, opts GenOptions) *zflag.FlagSet {
+	documented := zflag.NewFlagSet(fs.Name(), zflag.ContinueOnError)
+	documented.SortFlags = fs.SortFlags
+	documented.FlagUsageFormatter = fs.FlagUsageFormatter
+	for _, flag := range documentedFlags(fs, opts) {
+		visible := *flag
+		visible.Hidden = false
+		documented.AddFlag(&visible)
+	}
+	return documented
+}
+
+// deprecatedUsage returns usage with the deprecation notice of flag, if any,
+// like zflag prints it.
+func deprecatedUsage(flag *zflag.Flag, usage string) string {
+	if len(flag.Deprecated) == 0 {
+		return usage
+	}
+	return fmt.Sprintf("%s (DEPRECATED: %s)", usage, flag.Deprecated)
+}
+
+func isBooleanFlag(flag *zflag.Flag) bool {
+	_, isBool := flag.Value.(zflag.BoolFlag)
+	return isBool
+}
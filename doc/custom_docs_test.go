@@ -0,0 +1,155 @@
+package doc_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/doc"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestGenerateCustom(t *testing.T) {
+	_, echoCmd, echoSubCmd, _, deprecatedCmd, _, _ := getTestCmds()
+
+	tmplFile := filepath.Join(t.TempDir(), "custom.tmpl")
+	tmplText := `{{ .Title }}: {{ .Name }}
+{{ .Short | upper }}
+{{ range .Flags }}{{ .Name }}{{ if is_boolean . }} (bool){{ end }}
+{{ end }}{{ range .Children }}child: {{ .Name }}
+{{ end }}parent: {{ .Parent.Name }}
+`
+	testutil.AssertNil(t, os.WriteFile(tmplFile, []byte(tmplText), 0600))
+
+	buf := new(bytes.Buffer)
+	err := doc.GenerateCustom(
+		echoCmd,
+		buf,
+		tmplFile,
+		map[string]any{"Title": "Command"},
+		template.FuncMap{"upper": strings.ToUpper},
+	)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	output := buf.String()
+
+	testutil.AssertContains(t, output, "Command: root echo\n")
+	testutil.AssertContains(t, output, strings.ToUpper(echoCmd.Short))
+	testutil.AssertContains(t, output, "boolone (bool)\n")
+	testutil.AssertContains(t, output, "intone\n")
+	testutil.AssertContains(t, output, "child: "+echoSubCmd.Name())
+	testutil.AssertNotContains(t, output, "child: "+deprecatedCmd.Name())
+	testutil.AssertContains(t, output, "parent: root\n")
+}
+
+func TestGenerateCustomWithOptions(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().String("current", "", "current flag")
+	cmd.Flags().String("old", "", "old flag", zflag.OptDeprecated("use --current instead"))
+	cmd.Flags().String("secret", "", "secret flag", zflag.OptHidden())
+
+	tmplFile := filepath.Join(t.TempDir(), "custom.tmpl")
+	tmplText := `{{ range .Flags }}{{ .Name }}{{ if .Deprecated }} (deprecated){{ end }}
+{{ end }}`
+	testutil.AssertNil(t, os.WriteFile(tmplFile, []byte(tmplText), 0600))
+
+	testCases := []struct {
+		opts     doc.GenOptions
+		expected string
+	}{
+		{opts: doc.GenOptions{}, expected: "current\nhelp\n"},
+		{opts: doc.GenOptions{IncludeDeprecated: true}, expected: "current\nhelp\nold (deprecated)\n"},
+		{opts: doc.GenOptions{IncludeHidden: true}, expected: "current\nhelp\nsecret\n"},
+	}
+
+	for _, tc := range testCases {
+		buf := new(bytes.Buffer)
+		err := doc.GenerateCustomWithOptions(cmd, buf, tmplFile, nil, nil, tc.opts)
+		testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+		testutil.AssertEqualf(t, tc.expected, buf.String(), "Unexpected output for %+v", tc.opts)
+	}
+}
+
+func TestGenWithOptions(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().String("current", "", "current flag")
+	cmd.Flags().String("old", "", "old flag", zflag.OptDeprecated("use --current instead"))
+	cmd.Flags().String("secret", "", "secret flag", zflag.OptHidden())
+
+	generators := map[string]func(*zulu.Command, io.Writer, doc.GenOptions) error{
+		"markdown": doc.GenMarkdownWithOptions,
+		"man": func(cmd *zulu.Command, w io.Writer, opts doc.GenOptions) error {
+			return doc.GenManWithOptions(cmd, nil, w, opts)
+		},
+		"rest":     doc.GenReSTWithOptions,
+		"yaml":     doc.GenYamlWithOptions,
+		"asciidoc": doc.GenAsciidocWithOptions,
+	}
+
+	for name, gen := range generators {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			testutil.AssertNil(t, gen(cmd, buf, doc.GenOptions{}))
+			testutil.AssertContains(t, buf.String(), "current flag")
+			testutil.AssertNotContains(t, buf.String(), "old flag")
+			testutil.AssertNotContains(t, buf.String(), "secret flag")
+
+			buf.Reset()
+			testutil.AssertNil(t, gen(cmd, buf, doc.GenOptions{IncludeDeprecated: true}))
+			testutil.AssertContains(t, buf.String(), "old flag (DEPRECATED: use --current instead)")
+			testutil.AssertNotContains(t, buf.String(), "secret flag")
+
+			buf.Reset()
+			testutil.AssertNil(t, gen(cmd, buf, doc.GenOptions{IncludeHidden: true}))
+			testutil.AssertNotContains(t, buf.String(), "old flag")
+			testutil.AssertContains(t, buf.String(), "secret flag")
+		})
+	}
+}
+
+func TestGenerateCustomFlagGroups(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().String("user", "", "user")
+	cmd.Flags().String("token", "", "token")
+	cmd.MarkFlagsOneRequired("user", "token")
+
+	tmplFile := filepath.Join(t.TempDir(), "custom.tmpl")
+	tmplText := `{{ range .FlagGroups }}{{ .Kind }}: {{ range .FlagNames }}{{ . }} {{ end }}
+{{ . }}
+{{ end }}`
+	testutil.AssertNil(t, os.WriteFile(tmplFile, []byte(tmplText), 0600))
+
+	buf := new(bytes.Buffer)
+	err := doc.GenerateCustom(cmd, buf, tmplFile, nil, nil)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "one_required: user token \nAt least one of the flags --user, --token is required.\n", buf.String())
+}
+
+func TestGenerateCustomStructuredExamples(t *testing.T) {
+	cmd := &zulu.Command{
+		Use:      "root",
+		RunE:     emptyRun,
+		Examples: []zulu.CommandExample{{Command: "root --verbose", Description: "Run verbosely"}},
+	}
+
+	tmplFile := filepath.Join(t.TempDir(), "custom.tmpl")
+	tmplText := `{{ range .Examples }}{{ .Description }}: {{ .Command }}{{ end }}`
+	testutil.AssertNil(t, os.WriteFile(tmplFile, []byte(tmplText), 0600))
+
+	buf := new(bytes.Buffer)
+	err := doc.GenerateCustom(cmd, buf, tmplFile, nil, nil)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "Run verbosely: root --verbose", buf.String())
+}
+
+func TestGenerateCustomMissingTemplate(t *testing.T) {
+	_, echoCmd, _, _, _, _, _ := getTestCmds()
+
+	err := doc.GenerateCustom(echoCmd, new(bytes.Buffer), filepath.Join(t.TempDir(), "missing.tmpl"), nil, nil)
+	testutil.AssertErrf(t, err, "Expected an error")
+}
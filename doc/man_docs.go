@@ -102,6 +102,12 @@ type GenManHeader struct {
 // GenMan will generate a man page for the given command and write it to
 // w. The header argument may be nil, however obviously w may not.
 func GenMan(cmd *zulu.Command, header *GenManHeader, w io.Writer) error {
+	return GenManWithOptions(cmd, header, w, GenOptions{})
+}
+
+// GenManWithOptions is the same as GenMan, but opts can add the deprecated
+// and hidden flags to the options.
+func GenManWithOptions(cmd *zulu.Command, header *GenManHeader, w io.Writer, opts GenOptions) error {
 	if header == nil {
 		header = &GenManHeader{}
 	}
@@ -117,7 +123,7 @@ func GenMan(cmd *zulu.Command, header *GenManHeader, w io.Writer) error {
 		return err
 	}
 
-	b := genMan(cmd, header)
+	b := genMan(cmd, header, opts)
 	_, err := w.Write(md2man.Render(b))
 	return err
 }
@@ -198,12 +204,13 @@ func manPrintCommands(buf io.StringWriter, header *GenManHeader, cmd *zulu.Comma
 //nolint:gocognit // todo later
 func manPrintFlags(buf io.StringWriter, flags *zflag.FlagSet) {
 	flags.VisitAll(func(flag *zflag.Flag) {
-		if len(flag.Deprecated) > 0 || flag.Hidden {
+		if flag.Hidden {
 			return
 		}
 		format := ""
 
 		varname, usage := zflag.UnquoteUsage(flag)
+		usage = deprecatedUsage(flag, usage)
 
 		varname = strings.ReplaceAll(varname, "<", "\\<")
 		varname = strings.ReplaceAll(varname, ">", "\\>")
@@ -237,7 +244,7 @@ func manPrintFlags(buf io.StringWriter, flags *zflag.FlagSet) {
 		if !hasShorthand || !flag.ShorthandOnly { //nolint:nestif // todo refactor later
 			if isBoolean {
 				if flag.AddNegative {
-					format += ", **--[no-]%s**"
+					format += "**--[no-]%s**"
 				} else {
 					format += "**--%s**"
 				}
@@ -277,14 +284,14 @@ func manPrintFlags(buf io.StringWriter, flags *zflag.FlagSet) {
 	})
 }
 
-func manPrintOptions(buf io.StringWriter, command *zulu.Command) {
-	flags := command.NonInheritedFlags()
+func manPrintOptions(buf io.StringWriter, command *zulu.Command, opts GenOptions) {
+	flags := documentedFlagSet(command.NonInheritedFlags(), opts)
 	if flags.HasAvailableFlags() {
 		util.WriteStringAndCheck(buf, "# OPTIONS\n")
 		manPrintFlags(buf, flags)
 		util.WriteStringAndCheck(buf, "\n")
 	}
-	flags = command.InheritedFlags()
+	flags = documentedFlagSet(command.InheritedFlags(), opts)
 	if flags.HasAvailableFlags() {
 		util.WriteStringAndCheck(buf, "# OPTIONS INHERITED FROM PARENT COMMANDS\n")
 		manPrintFlags(buf, flags)
@@ -292,7 +299,19 @@ func manPrintOptions(buf io.StringWriter, command *zulu.Command) {
 	}
 }
 
-func genMan(cmd *zulu.Command, header *GenManHeader) []byte {
+func manPrintFlagGroups(buf io.StringWriter, command *zulu.Command) {
+	relations := command.FlagGroupRelations()
+	if len(relations) == 0 {
+		return
+	}
+
+	util.WriteStringAndCheck(buf, "# FLAG CONSTRAINTS\n")
+	for _, relation := range relations {
+		util.WriteStringAndCheck(buf, relation.String()+"\n\n")
+	}
+}
+
+func genMan(cmd *zulu.Command, header *GenManHeader, opts GenOptions) []byte {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
 	cmd.InitDefaultCompletionCmd()
@@ -304,10 +323,11 @@ func genMan(cmd *zulu.Command, header *GenManHeader) []byte {
 
 	manPreamble(buf, header, cmd, dashCommandName)
 	manPrintCommands(buf, header, cmd)
-	manPrintOptions(buf, cmd)
-	if len(cmd.Example) > 0 {
+	manPrintOptions(buf, cmd, opts)
+	manPrintFlagGroups(buf, cmd)
+	if cmd.HasExample() {
 		buf.WriteString("# EXAMPLE\n")
-		buf.WriteString(fmt.Sprintf("```\n%s\n```\n", cmd.Example))
+		buf.WriteString(fmt.Sprintf("```\n%s\n```\n", cmd.ExampleText()))
 	}
 	if hasSeeAlso(cmd) {
 		buf.WriteString("# SEE ALSO\n")
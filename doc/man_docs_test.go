@@ -193,6 +193,19 @@ func TestManPrintFlagsHidesShortDeprecated(t *testing.T) {
 	}
 }
 
+func TestManPrintFlagsBoolNegative(t *testing.T) {
+	c := &zulu.Command{}
+	c.Flags().Bool("foo", false, "Foo flag", zflag.OptAddNegative())
+	c.Flags().Bool("bar", false, "Bar flag", zflag.OptShorthand('b'), zflag.OptAddNegative())
+
+	buf := new(bytes.Buffer)
+	doc.ManPrintFlags(buf, c.Flags())
+
+	got := buf.String()
+	expected := "**-b**, **--[no-]bar**\n\n\tBar flag\n\n**--[no-]foo**\n\n\tFoo flag\n\n"
+	testutil.AssertEqual(t, expected, got)
+}
+
 func TestGenManCommands(t *testing.T) {
 	rootCmd, echoCmd, _, timesCmd, _, _, _ := getTestCmds()
 	header := &doc.GenManHeader{
@@ -248,6 +261,25 @@ See \*\*root\-echo\-echosub\(2\)\*\*\.
 	testutil.AssertNotContains(t, output, ".SH COMMANDS")
 }
 
+func TestGenManFlagGroups(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().Bool("json", false, "json output")
+	cmd.Flags().Bool("yaml", false, "yaml output")
+	cmd.Flags().Bool("secret", false, "secret", zflag.OptHidden())
+	cmd.MarkFlagsMutuallyExclusiveAndOneRequired("json", "yaml", "secret")
+	cmd.MarkFlagsRequiredTogether("json", "secret")
+
+	buf := new(bytes.Buffer)
+	if err := doc.GenMan(cmd, &doc.GenManHeader{Title: "Project", Section: "2"}, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	testutil.AssertContains(t, output, "FLAG CONSTRAINTS")
+	testutil.AssertContains(t, output, "Exactly one of the flags --json, --yaml is required.")
+	testutil.AssertNotContains(t, output, "secret")
+}
+
 func TestGenManTree(t *testing.T) {
 	c := &zulu.Command{Use: "do [OPTIONS] arg1 arg2"}
 	header := &doc.GenManHeader{Section: "2"}
@@ -26,8 +26,8 @@ import (
 	"github.com/zulucmd/zulu/v2"
 )
 
-func printOptions(buf *bytes.Buffer, cmd *zulu.Command) {
-	flags := cmd.NonInheritedFlags()
+func printOptions(buf *bytes.Buffer, cmd *zulu.Command, opts GenOptions) {
+	flags := documentedFlagSet(cmd.NonInheritedFlags(), opts)
 	flags.SetOutput(buf)
 	if flags.HasAvailableFlags() {
 		buf.WriteString("### Options\n\n```\n")
@@ -35,7 +35,7 @@ func printOptions(buf *bytes.Buffer, cmd *zulu.Command) {
 		buf.WriteString("```\n\n")
 	}
 
-	parentFlags := cmd.InheritedFlags()
+	parentFlags := documentedFlagSet(cmd.InheritedFlags(), opts)
 	parentFlags.SetOutput(buf)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("### Options inherited from parent commands\n\n```\n")
@@ -44,13 +44,42 @@ func printOptions(buf *bytes.Buffer, cmd *zulu.Command) {
 	}
 }
 
+func printFlagGroupsMarkdown(buf *bytes.Buffer, cmd *zulu.Command) {
+	relations := cmd.FlagGroupRelations()
+	if len(relations) == 0 {
+		return
+	}
+
+	buf.WriteString("### Flag constraints\n\n")
+	for _, relation := range relations {
+		buf.WriteString("* " + relation.String() + "\n")
+	}
+	buf.WriteString("\n")
+}
+
 // GenMarkdown creates markdown output.
 func GenMarkdown(cmd *zulu.Command, w io.Writer) error {
 	return GenMarkdownCustom(cmd, w, func(s string) string { return s })
 }
 
+// GenMarkdownWithOptions is the same as GenMarkdown, but opts can add the
+// deprecated and hidden flags to the options.
+func GenMarkdownWithOptions(cmd *zulu.Command, w io.Writer, opts GenOptions) error {
+	return genMarkdown(cmd, w, func(s string) string { return s }, isDocumented, opts)
+}
+
 // GenMarkdownCustom creates custom markdown output.
 func GenMarkdownCustom(cmd *zulu.Command, w io.Writer, linkHandler func(string) string) error {
+	return genMarkdown(cmd, w, linkHandler, isDocumented, GenOptions{})
+}
+
+func genMarkdown(
+	cmd *zulu.Command,
+	w io.Writer,
+	linkHandler func(string) string,
+	documented func(*zulu.Command) bool,
+	opts GenOptions,
+) error {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
 	cmd.InitDefaultCompletionCmd()
@@ -69,13 +98,14 @@ func GenMarkdownCustom(cmd *zulu.Command, w io.Writer, linkHandler func(string)
 		buf.WriteString(fmt.Sprintf("```\n%s\n```\n\n", cmd.UseLine()))
 	}
 
-	if len(cmd.Example) > 0 {
+	if cmd.HasExample() {
 		buf.WriteString("### Examples\n\n")
-		buf.WriteString(fmt.Sprintf("```\n%s\n```\n\n", cmd.Example))
+		buf.WriteString(fmt.Sprintf("```\n%s\n```\n\n", cmd.ExampleText()))
 	}
 
-	printOptions(buf, cmd)
-	printSeeAlsoMarkdown(cmd, buf, linkHandler, name)
+	printOptions(buf, cmd, opts)
+	printFlagGroupsMarkdown(buf, cmd)
+	printSeeAlsoMarkdown(cmd, buf, linkHandler, name, documented)
 
 	if !cmd.DisableAutoGenTag {
 		buf.WriteString("###### Auto generated by zulucmd/zulu on " + time.Now().Format("2-Jan-2006") + "\n")
@@ -84,8 +114,14 @@ func GenMarkdownCustom(cmd *zulu.Command, w io.Writer, linkHandler func(string)
 	return err
 }
 
-func printSeeAlsoMarkdown(cmd *zulu.Command, buf *bytes.Buffer, linkHandler func(string) string, name string) {
-	if !hasSeeAlso(cmd) {
+func printSeeAlsoMarkdown(
+	cmd *zulu.Command,
+	buf *bytes.Buffer,
+	linkHandler func(string) string,
+	name string,
+	documented func(*zulu.Command) bool,
+) {
+	if !hasSeeAlsoFunc(cmd, documented) {
 		return
 	}
 
@@ -107,7 +143,7 @@ func printSeeAlsoMarkdown(cmd *zulu.Command, buf *bytes.Buffer, linkHandler func
 	sort.Sort(byName(children))
 
 	for _, child := range children {
-		if !child.IsAvailableCommand() || child.IsAdditionalHelpTopicCommand() {
+		if !documented(child) {
 			continue
 		}
 		cname := name + " " + child.Name()
@@ -133,11 +169,70 @@ func GenMarkdownTree(cmd *zulu.Command, dir string) error {
 // GenMarkdownTreeCustom is the the same as GenMarkdownTree, but
 // with custom filePrepender and linkHandler.
 func GenMarkdownTreeCustom(cmd *zulu.Command, dir string, filePrepender, linkHandler func(string) string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return genMarkdownTree(cmd, dir, filePrepender, linkHandler, isDocumented)
+}
+
+// GenMarkdownTreeOptions is the options for generating the markdown docs.
+// Used only in GenMarkdownTreeFromOpts.
+type GenMarkdownTreeOptions struct {
+	// Path is the directory the pages are written to. It is created if it does not exist.
+	Path string
+	// FilePrepender returns the content to prepend to the page written to the given file.
+	FilePrepender func(string) string
+	// LinkHandler returns the link to the page written to the given file.
+	LinkHandler func(string) string
+	// IncludeDefaultCmds generates pages for the help and completion commands added by zulu.
+	IncludeDefaultCmds bool
+}
+
+// GenMarkdownTreeFromOpts generates a markdown page for the command and all descendants.
+// The pages are written to the opts.Path directory.
+func GenMarkdownTreeFromOpts(cmd *zulu.Command, opts GenMarkdownTreeOptions) error {
+	filePrepender := opts.FilePrepender
+	if filePrepender == nil {
+		filePrepender = func(_ string) string { return "" }
+	}
+	linkHandler := opts.LinkHandler
+	if linkHandler == nil {
+		linkHandler = func(s string) string { return s }
+	}
+
+	documented := func(c *zulu.Command) bool {
+		if _, ok := c.Annotations[zulu.CommandSetByZuluAnnotation]; ok {
+			return opts.IncludeDefaultCmds && !c.Hidden
+		}
+		return isDocumented(c)
+	}
+
+	if err := os.MkdirAll(opts.Path, 0755); err != nil {
+		return err
+	}
+
+	// Add the completion command before walking the subcommands, so it gets a page too.
+	cmd.InitDefaultCompletionCmd()
+
+	return genMarkdownTree(cmd, opts.Path, filePrepender, linkHandler, documented)
+}
+
+func genMarkdownTree(
+	cmd *zulu.Command,
+	dir string,
+	filePrepender, linkHandler func(string) string,
+	documented func(*zulu.Command) bool,
+) error {
+	// The help command is added when generating the page, add it before walking
+	// the subcommands so the page and the subcommands walked are consistent.
+	cmd.InitDefaultHelpCmd()
+
 	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+		if !documented(c) {
 			continue
 		}
-		if err := GenMarkdownTreeCustom(c, dir, filePrepender, linkHandler); err != nil {
+		if err := genMarkdownTree(c, dir, filePrepender, linkHandler, documented); err != nil {
 			return err
 		}
 	}
@@ -154,5 +249,5 @@ func GenMarkdownTreeCustom(cmd *zulu.Command, dir string, filePrepender, linkHan
 		return err
 	}
 
-	return GenMarkdownCustom(cmd, f, linkHandler)
+	return genMarkdown(cmd, f, linkHandler, documented, GenOptions{})
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2"
 	"github.com/zulucmd/zulu/v2/doc"
 	"github.com/zulucmd/zulu/v2/internal/testutil"
@@ -66,6 +67,51 @@ func TestGenMdNoHiddenParents(t *testing.T) {
 	testutil.AssertNotContains(t, output, "Options inherited from parent commands")
 }
 
+func TestGenMdFlagGroups(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().String("user", "", "user")
+	cmd.Flags().String("password", "", "password")
+	cmd.Flags().Bool("json", false, "json output")
+	cmd.Flags().Bool("yaml", false, "yaml output")
+	cmd.Flags().String("secret", "", "secret", zflag.OptHidden())
+	cmd.MarkFlagsRequiredTogether("user", "password")
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml", "secret")
+	cmd.MarkFlagsRequiredTogether("user", "secret")
+
+	buf := new(bytes.Buffer)
+	if err := doc.GenMarkdown(cmd, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	testutil.AssertContains(t, output, `### Flag constraints
+
+* The flags --user, --password must be used together.
+* The flags --json, --yaml are mutually exclusive.
+
+`)
+	testutil.AssertNotContains(t, output, "--secret")
+}
+
+func TestGenMdStructuredExamples(t *testing.T) {
+	cmd := &zulu.Command{
+		Use:  "root",
+		RunE: emptyRun,
+		Examples: []zulu.CommandExample{
+			{Command: "root --verbose", Description: "Run verbosely"},
+			{Command: "root"},
+		},
+	}
+	cmd.Flags().Bool("verbose", false, "verbose")
+
+	buf := new(bytes.Buffer)
+	if err := doc.GenMarkdown(cmd, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.AssertContains(t, buf.String(), "### Examples\n\n```\n# Run verbosely\nroot --verbose\n\nroot\n```\n")
+}
+
 func TestGenMdNoTag(t *testing.T) {
 	rootCmd, _, _, _, _, _, _ := getTestCmds()
 	rootCmd.DisableAutoGenTag = true
@@ -92,6 +138,42 @@ func TestGenMdTree(t *testing.T) {
 	}
 }
 
+func TestGenMdTreeFromOpts(t *testing.T) {
+	for _, includeDefaultCmds := range []bool{false, true} {
+		rootCmd := &zulu.Command{Use: "root", RunE: emptyRun}
+		rootCmd.AddCommand(&zulu.Command{Use: "child", Short: "child command", RunE: emptyRun})
+		tmpdir := filepath.Join(t.TempDir(), "docs", "cli")
+
+		err := doc.GenMarkdownTreeFromOpts(rootCmd, doc.GenMarkdownTreeOptions{
+			Path:               tmpdir,
+			IncludeDefaultCmds: includeDefaultCmds,
+		})
+		if err != nil {
+			t.Fatalf("GenMarkdownTreeFromOpts failed: %v", err)
+		}
+
+		for _, name := range []string{"root.md", "root_child.md"} {
+			if _, err := os.Stat(filepath.Join(tmpdir, name)); err != nil {
+				t.Errorf("Expected file %q to exist", name)
+			}
+		}
+
+		for _, name := range []string{"root_help.md", "root_completion.md", "root_completion_bash.md"} {
+			_, err := os.Stat(filepath.Join(tmpdir, name))
+			testutil.AssertEqualf(t, includeDefaultCmds, err == nil, "Unexpected existence of file %q", name)
+		}
+
+		output, err := os.ReadFile(filepath.Join(tmpdir, "root.md"))
+		testutil.AssertNil(t, err)
+		testutil.AssertContains(t, string(output), "(root_child.md)")
+		if includeDefaultCmds {
+			testutil.AssertContains(t, string(output), "(root_completion.md)")
+		} else {
+			testutil.AssertNotContains(t, string(output), "(root_completion.md)")
+		}
+	}
+}
+
 func BenchmarkGenMarkdownToFile(b *testing.B) {
 	rootCmd, _, _, _, _, _, _ := getTestCmds()
 	file, err := os.CreateTemp(b.TempDir(), "")
@@ -23,32 +23,88 @@ import (
 	"strings"
 	"time"
 
+	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2"
 )
 
 type linkHandlerFn func(string, string) string
 
-func printOptionsReST(buf *bytes.Buffer, cmd *zulu.Command) error {
-	flags := cmd.NonInheritedFlags()
-	flags.SetOutput(buf)
+func printOptionsReST(buf *bytes.Buffer, cmd *zulu.Command, opts GenOptions) error {
+	flags := documentedFlagSet(cmd.NonInheritedFlags(), opts)
 	if flags.HasAvailableFlags() {
 		buf.WriteString("Options\n")
-		buf.WriteString("~~~~~~~\n\n::\n\n")
-		flags.PrintDefaults()
-		buf.WriteString("\n")
+		buf.WriteString("~~~~~~~\n\n")
+		printFlagsReST(buf, flags)
 	}
 
-	parentFlags := cmd.InheritedFlags()
-	parentFlags.SetOutput(buf)
+	parentFlags := documentedFlagSet(cmd.InheritedFlags(), opts)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("Options inherited from parent commands\n")
-		buf.WriteString("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n::\n\n")
-		parentFlags.PrintDefaults()
-		buf.WriteString("\n")
+		buf.WriteString("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n\n")
+		printFlagsReST(buf, parentFlags)
 	}
 	return nil
 }
 
+// printFlagsReST writes the visible flags as a definition list, with the flag
+// names as the terms and the usage and default value as the definitions.
+func printFlagsReST(buf *bytes.Buffer, flags *zflag.FlagSet) {
+	flags.VisitAll(func(flag *zflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+
+		varname, usage := zflag.UnquoteUsage(flag)
+		usage = deprecatedUsage(flag, usage)
+		_, isBoolean := flag.Value.(zflag.BoolFlag)
+
+		var names []string
+		if flag.Shorthand > 0 && len(flag.ShorthandDeprecated) == 0 {
+			names = append(names, fmt.Sprintf("-%c", flag.Shorthand))
+		}
+		if !flag.ShorthandOnly {
+			if isBoolean && flag.AddNegative {
+				names = append(names, "--[no-]"+flag.Name)
+			} else {
+				names = append(names, "--"+flag.Name)
+			}
+		}
+
+		term := strings.Join(names, ", ")
+		if varname != "" {
+			term += " " + varname
+		}
+		buf.WriteString("``" + term + "``\n")
+
+		var definition []string
+		if usage != "" {
+			definition = append(definition, usage)
+		}
+		if flag.DefValue != "" && !isBoolean {
+			definition = append(definition, fmt.Sprintf("Defaults to: ``%s``", flag.DefValue))
+		}
+		if len(definition) == 0 {
+			// An escaped newline keeps the definition empty but valid.
+			definition = append(definition, "\\")
+		}
+		buf.WriteString(indentString(strings.Join(definition, "\n\n"), "  ") + "\n\n")
+	})
+}
+
+func printFlagGroupsReST(buf *bytes.Buffer, cmd *zulu.Command) {
+	relations := cmd.FlagGroupRelations()
+	if len(relations) == 0 {
+		return
+	}
+
+	buf.WriteString("Flag constraints\n")
+	buf.WriteString("~~~~~~~~~~~~~~~~\n\n")
+	for _, relation := range relations {
+		buf.WriteString("* " + relation.String() + "\n")
+	}
+	buf.WriteString("\n")
+}
+
 // linkHandler for default ReST hyperlink markup.
 func defaultLinkHandler(name, ref string) string {
 	return fmt.Sprintf("`%s <%s.rst>`_", name, ref)
@@ -59,8 +115,18 @@ func GenReST(cmd *zulu.Command, w io.Writer) error {
 	return GenReSTCustom(cmd, w, defaultLinkHandler)
 }
 
+// GenReSTWithOptions is the same as GenReST, but opts can add the deprecated
+// and hidden flags to the options.
+func GenReSTWithOptions(cmd *zulu.Command, w io.Writer, opts GenOptions) error {
+	return genReST(cmd, w, defaultLinkHandler, opts)
+}
+
 // GenReSTCustom creates custom reStructured Text output.
 func GenReSTCustom(cmd *zulu.Command, w io.Writer, linkHandler linkHandlerFn) error {
+	return genReST(cmd, w, linkHandler, GenOptions{})
+}
+
+func genReST(cmd *zulu.Command, w io.Writer, linkHandler linkHandlerFn, opts GenOptions) error {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
 	cmd.InitDefaultCompletionCmd()
@@ -87,15 +153,16 @@ func GenReSTCustom(cmd *zulu.Command, w io.Writer, linkHandler linkHandlerFn) er
 		buf.WriteString(fmt.Sprintf("::\n\n  %s\n\n", cmd.UseLine()))
 	}
 
-	if len(cmd.Example) > 0 {
+	if cmd.HasExample() {
 		buf.WriteString("Examples\n")
 		buf.WriteString("~~~~~~~~\n\n")
-		buf.WriteString(fmt.Sprintf("::\n\n%s\n\n", indentString(cmd.Example, "  ")))
+		buf.WriteString(fmt.Sprintf("::\n\n%s\n\n", indentString(cmd.ExampleText(), "  ")))
 	}
 
-	if err := printOptionsReST(buf, cmd); err != nil {
+	if err := printOptionsReST(buf, cmd, opts); err != nil {
 		return err
 	}
+	printFlagGroupsReST(buf, cmd)
 	printSeeAlsoReST(cmd, buf, linkHandler, name)
 	if !cmd.DisableAutoGenTag {
 		buf.WriteString("*Auto generated by zulucmd/zulu on " + time.Now().Format("2-Jan-2006") + "*\n")
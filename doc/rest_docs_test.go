@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2"
 	"github.com/zulucmd/zulu/v2/doc"
 	"github.com/zulucmd/zulu/v2/internal/testutil"
@@ -29,6 +30,42 @@ func TestGenRSTDoc(t *testing.T) {
 	testutil.AssertNotContains(t, output, deprecatedCmd.Short)
 }
 
+func TestGenRSTFlagsDefinitionList(t *testing.T) {
+	_, echoCmd, _, _, _, _, _ := getTestCmds()
+	buf := new(bytes.Buffer)
+	if err := doc.GenReST(echoCmd, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	testutil.AssertContains(t, output, "``-b, --boolone``\n  help message for flag boolone\n\n")
+	testutil.AssertContains(t, output, "``-s, --strone string``\n  help message for flag strone\n\n  Defaults to: ``one``\n\n")
+	testutil.AssertContains(t, output, "``-r, --rootflag string``\n  Defaults to: ``two``\n\n")
+}
+
+func TestGenRSTFlagGroups(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().Bool("json", false, "json output")
+	cmd.Flags().Bool("yaml", false, "yaml output")
+	cmd.Flags().Bool("secret", false, "secret", zflag.OptHidden())
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml", "secret")
+	cmd.MarkFlagsRequiredTogether("json", "secret")
+
+	buf := new(bytes.Buffer)
+	if err := doc.GenReST(cmd, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	testutil.AssertContains(t, output, `Flag constraints
+~~~~~~~~~~~~~~~~
+
+* The flags --json, --yaml are mutually exclusive.
+
+`)
+	testutil.AssertNotContains(t, output, "secret")
+}
+
 func TestGenRSTNoHiddenParents(t *testing.T) {
 	rootCmd, echoCmd, echoSubCmd, _, deprecatedCmd, _, _ := getTestCmds()
 	// We generate on a subcommand so we have both subcommands and parents
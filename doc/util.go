@@ -23,18 +23,29 @@ import (
 // Basically this is a test for a parent command or a subcommand which is
 // both not deprecated and not the autogenerated help command.
 func hasSeeAlso(cmd *zulu.Command) bool {
+	return hasSeeAlsoFunc(cmd, isDocumented)
+}
+
+// hasSeeAlsoFunc is the same as hasSeeAlso, but the subcommands that are
+// documented are determined by the documented function.
+func hasSeeAlsoFunc(cmd *zulu.Command, documented func(*zulu.Command) bool) bool {
 	if cmd.HasParent() {
 		return true
 	}
 	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
-			continue
+		if documented(c) {
+			return true
 		}
-		return true
 	}
 	return false
 }
 
+// isDocumented returns true if cmd gets its own page when generating the docs
+// for a command tree, which is when it is available and not a help topic.
+func isDocumented(cmd *zulu.Command) bool {
+	return cmd.IsAvailableCommand() && !cmd.IsAdditionalHelpTopicCommand()
+}
+
 // A temporary workaround for yaml lib generating incorrect yaml with long strings
 // that do not contain \n.
 func forceMultiLine(s string) string {
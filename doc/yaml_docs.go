@@ -37,9 +37,12 @@ type cmdDoc struct {
 	Synopsis         string      `yaml:",omitempty"`
 	Description      string      `yaml:",omitempty"`
 	Usage            string      `yaml:",omitempty"`
+	Aliases          []string    `yaml:",omitempty"`
 	Options          []cmdOption `yaml:",omitempty"`
 	InheritedOptions []cmdOption `yaml:"inherited_options,omitempty"`
+	FlagConstraints  []string    `yaml:"flag_constraints,omitempty"`
 	Example          string      `yaml:",omitempty"`
+	Commands         []string    `yaml:",omitempty"`
 	SeeAlso          []string    `yaml:"see_also,omitempty"`
 }
 
@@ -85,8 +88,18 @@ func GenYaml(cmd *zulu.Command, w io.Writer) error {
 	return GenYamlCustom(cmd, w, func(s string) string { return s })
 }
 
+// GenYamlWithOptions is the same as GenYaml, but opts can add the deprecated
+// and hidden flags to the options.
+func GenYamlWithOptions(cmd *zulu.Command, w io.Writer, opts GenOptions) error {
+	return genYaml(cmd, w, opts)
+}
+
 // GenYamlCustom creates custom yaml output.
 func GenYamlCustom(cmd *zulu.Command, w io.Writer, linkHandler func(string) string) error {
+	return genYaml(cmd, w, GenOptions{})
+}
+
+func genYaml(cmd *zulu.Command, w io.Writer, opts GenOptions) error {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
 	cmd.InitDefaultCompletionCmd()
@@ -101,27 +114,39 @@ func GenYamlCustom(cmd *zulu.Command, w io.Writer, linkHandler func(string) stri
 		yamlDoc.Usage = cmd.UseLine()
 	}
 
-	if len(cmd.Example) > 0 {
-		yamlDoc.Example = cmd.Example
+	yamlDoc.Aliases = cmd.Aliases
+
+	if cmd.HasExample() {
+		yamlDoc.Example = cmd.ExampleText()
 	}
 
-	flags := cmd.NonInheritedFlags()
+	flags := documentedFlagSet(cmd.NonInheritedFlags(), opts)
 	if flags.HasFlags() {
 		yamlDoc.Options = genFlagResult(flags)
 	}
-	flags = cmd.InheritedFlags()
+	flags = documentedFlagSet(cmd.InheritedFlags(), opts)
 	if flags.HasFlags() {
 		yamlDoc.InheritedOptions = genFlagResult(flags)
 	}
 
+	for _, relation := range cmd.FlagGroupRelations() {
+		yamlDoc.FlagConstraints = append(yamlDoc.FlagConstraints, relation.String())
+	}
+
+	children := cmd.Commands()
+	sort.Sort(byName(children))
+	for _, child := range children {
+		if child.IsAvailableCommand() && !child.IsAdditionalHelpTopicCommand() {
+			yamlDoc.Commands = append(yamlDoc.Commands, child.Name())
+		}
+	}
+
 	if hasSeeAlso(cmd) {
 		var result []string
 		if cmd.HasParent() {
 			parent := cmd.Parent()
 			result = append(result, parent.CommandPath()+" - "+parent.Short)
 		}
-		children := cmd.Commands()
-		sort.Sort(byName(children))
 		for _, child := range children {
 			if !child.IsAvailableCommand() || child.IsAdditionalHelpTopicCommand() {
 				continue
@@ -153,14 +178,14 @@ func genFlagResult(flags *zflag.FlagSet) []cmdOption {
 				flag.Name,
 				flag.Shorthand,
 				flag.DefValue,
-				forceMultiLine(flag.Usage),
+				forceMultiLine(deprecatedUsage(flag, flag.Usage)),
 			}
 			result = append(result, opt)
 		} else {
 			opt := cmdOption{
 				Name:         flag.Name,
 				DefaultValue: forceMultiLine(flag.DefValue),
-				Usage:        forceMultiLine(flag.Usage),
+				Usage:        forceMultiLine(deprecatedUsage(flag, flag.Usage)),
 			}
 			result = append(result, opt)
 		}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2"
 	"github.com/zulucmd/zulu/v2/doc"
 	"github.com/zulucmd/zulu/v2/internal/testutil"
@@ -69,6 +70,37 @@ func TestGenYamlDocRunnable(t *testing.T) {
 	testutil.AssertContains(t, output, "usage: "+rootCmd.Use)
 }
 
+func TestGenYamlAliasesAndCommands(t *testing.T) {
+	_, echoCmd, _, _, _, _, _ := getTestCmds()
+	buf := new(bytes.Buffer)
+	if err := doc.GenYaml(echoCmd, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	testutil.AssertContains(t, output, "aliases:\n    - say\n")
+	testutil.AssertContains(t, output, "commands:\n    - echosub\n    - times\n")
+	testutil.AssertNotContains(t, output, "    - deprecated\n")
+}
+
+func TestGenYamlFlagGroups(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: emptyRun}
+	cmd.Flags().Bool("json", false, "json output")
+	cmd.Flags().Bool("yaml", false, "yaml output")
+	cmd.Flags().Bool("secret", false, "secret", zflag.OptHidden())
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml", "secret")
+	cmd.MarkFlagsRequiredTogether("json", "secret")
+
+	buf := new(bytes.Buffer)
+	if err := doc.GenYaml(cmd, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	testutil.AssertContains(t, output, "flag_constraints:\n    - The flags --json, --yaml are mutually exclusive.\n")
+	testutil.AssertNotContains(t, output, "--secret")
+}
+
 func BenchmarkGenYamlToFile(b *testing.B) {
 	rootCmd, _, _, _, _, _, _ := getTestCmds()
 	file, err := os.CreateTemp(b.TempDir(), "")
@@ -0,0 +1,81 @@
+package zulu
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/zulucmd/zflag/v2"
+)
+
+// commandJSON is the JSON representation of a command written by DumpJSON.
+type commandJSON struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Short    string        `json:"short"`
+	Long     string        `json:"long"`
+	Example  string        `json:"example"`
+	Flags    []flagJSON    `json:"flags"`
+	Commands []commandJSON `json:"commands"`
+}
+
+// flagJSON is the JSON representation of a flag written by DumpJSON.
+type flagJSON struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand"`
+	Type      string `json:"type"`
+	Default   string `json:"default"`
+	Usage     string `json:"usage"`
+	Required  bool   `json:"required"`
+}
+
+// DumpJSON writes a JSON description of the command and its available
+// subcommands to w, e.g. for editor integrations. Each command has a name,
+// path, short, long, example, flags and commands field, and each flag a name,
+// shorthand, type, default, usage and required field. The flags are the local
+// flags of the command, leaving out the hidden and deprecated ones, and the
+// subcommands are sorted by name.
+func (c *Command) DumpJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.toJSON())
+}
+
+func (c *Command) toJSON() commandJSON {
+	cmd := commandJSON{
+		Name:     c.Name(),
+		Path:     c.CommandPath(),
+		Short:    c.Short,
+		Long:     c.Long,
+		Example:  c.ExampleText(),
+		Flags:    []flagJSON{},
+		Commands: []commandJSON{},
+	}
+
+	c.LocalFlags().VisitAll(func(f *zflag.Flag) {
+		if nonCompletableFlag(f) {
+			return
+		}
+
+		flag := flagJSON{
+			Name:     f.Name,
+			Default:  f.DefValue,
+			Usage:    f.Usage,
+			Required: f.Required,
+		}
+		if f.Shorthand > 0 {
+			flag.Shorthand = string(f.Shorthand)
+		}
+		if typed, ok := f.Value.(zflag.Typed); ok {
+			flag.Type = typed.Type()
+		}
+		cmd.Flags = append(cmd.Flags, flag)
+	})
+
+	for _, sub := range c.commandsByName() {
+		if sub.IsAvailableCommand() {
+			cmd.Commands = append(cmd.Commands, sub.toJSON())
+		}
+	}
+
+	return cmd
+}
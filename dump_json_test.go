@@ -0,0 +1,76 @@
+package zulu_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestDumpJSON(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Short: "root short", Long: "root long", RunE: noopRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output", zflag.OptShorthand('v'))
+	rootCmd.Flags().String("secret", "", "secret usage", zflag.OptHidden())
+	childCmd := &zulu.Command{Use: "child", Short: "child short", Example: "root child --count 2", RunE: noopRun}
+	childCmd.Flags().Int("count", 1, "count usage", zflag.OptRequired())
+	rootCmd.AddCommand(
+		childCmd,
+		&zulu.Command{Use: "hidden", Hidden: true, RunE: noopRun},
+		&zulu.Command{Use: "another", RunE: noopRun},
+	)
+
+	var buf bytes.Buffer
+	err := rootCmd.DumpJSON(&buf)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := `{
+  "name": "root",
+  "path": "root",
+  "short": "root short",
+  "long": "root long",
+  "example": "",
+  "flags": [
+    {
+      "name": "verbose",
+      "shorthand": "v",
+      "type": "bool",
+      "default": "false",
+      "usage": "verbose output",
+      "required": false
+    }
+  ],
+  "commands": [
+    {
+      "name": "another",
+      "path": "root another",
+      "short": "",
+      "long": "",
+      "example": "",
+      "flags": [],
+      "commands": []
+    },
+    {
+      "name": "child",
+      "path": "root child",
+      "short": "child short",
+      "long": "",
+      "example": "root child --count 2",
+      "flags": [
+        {
+          "name": "count",
+          "shorthand": "",
+          "type": "int",
+          "default": "1",
+          "usage": "count usage",
+          "required": true
+        }
+      ],
+      "commands": []
+    }
+  ]
+}
+`
+	testutil.AssertEqual(t, expected, buf.String())
+}
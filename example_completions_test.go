@@ -0,0 +1,47 @@
+package zulu_test
+
+import (
+	"os"
+
+	"github.com/zulucmd/zulu/v2"
+)
+
+func ExampleFlagOptCompletionFunc() {
+	regionsByProfile := map[string][]string{
+		"dev":  {"dev-east", "dev-west"},
+		"prod": {"prod-east"},
+	}
+
+	rootCmd := &zulu.Command{Use: "root"}
+	rootCmd.PersistentFlags().String("profile", "dev", "profile to use")
+
+	deployCmd := &zulu.Command{
+		Use:  "deploy",
+		RunE: func(cmd *zulu.Command, args []string) error { return nil },
+	}
+	deployCmd.Flags().String(
+		"region",
+		"",
+		"region to deploy to",
+		zulu.FlagOptCompletionFunc(func(
+			cmd *zulu.Command,
+			args []string,
+			toComplete string,
+		) ([]string, zulu.ShellCompDirective) {
+			// Flags, including persistent flags of parent commands, are
+			// parsed before the completion function is called.
+			profile := cmd.Flag("profile").Value.String()
+			return regionsByProfile[profile], zulu.ShellCompDirectiveNoFileComp
+		}),
+	)
+	rootCmd.AddCommand(deployCmd)
+
+	rootCmd.SetOut(os.Stdout)
+	rootCmd.SetErr(os.Stdout)
+	rootCmd.SetArgs([]string{zulu.ShellCompNoDescRequestCmd, "--profile", "prod", "deploy", "--region", ""})
+	_ = rootCmd.Execute()
+	// Output:
+	// prod-east
+	// :4
+	// Completion ended with directive: ShellCompDirectiveNoFileComp
+}
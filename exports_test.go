@@ -8,5 +8,10 @@ const (
 
 var StripFlags = stripFlags
 var StringInSlice = stringInSlice
+var PathSearchDir = pathSearchDir
 
 var ShellCompDirectiveMaxValue = shellCompDirectiveMaxValue
+
+func RemoveTemplateFunc(name string) {
+	delete(templateFuncs, name)
+}
@@ -0,0 +1,86 @@
+package zulu
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// EnableExternalCommands makes the unknown subcommands of the command run the
+// executable named prefix followed by the subcommand name, looked up in PATH,
+// git-style. E.g. with the prefix "mycli-", "mycli foo arg" runs "mycli-foo arg"
+// when mycli has no foo subcommand. An empty prefix stands for the name of the
+// root command followed by a dash. The executable inherits the input and
+// outputs of the command, and the external commands found in PATH are
+// completed along with the subcommands. The function set with
+// SetUnknownCommandFunc still handles the names without an executable.
+func (c *Command) EnableExternalCommands(prefix string) {
+	if prefix == "" {
+		prefix = c.Root().Name() + "-"
+	}
+	c.externalCommandPrefix = prefix
+}
+
+// lookupExternalCommand returns the path of the executable of the external
+// command name, if external commands are enabled and it exists. Names with a
+// path separator are rejected, as they would be resolved relatively to the
+// current directory instead of PATH.
+func (c *Command) lookupExternalCommand(name string) (string, bool) {
+	if c.externalCommandPrefix == "" || strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		return "", false
+	}
+	path, err := exec.LookPath(c.externalCommandPrefix + name)
+	return path, err == nil
+}
+
+// runExternalCommand runs the executable at path with args, inheriting the
+// context, input and outputs of the command.
+func (c *Command) runExternalCommand(path string, args []string) error {
+	cmd := exec.CommandContext(c.Context(), path, args...)
+	cmd.Stdin = c.InOrStdin()
+	cmd.Stdout = c.OutOrStdout()
+	cmd.Stderr = c.ErrOrStderr()
+	return cmd.Run()
+}
+
+// externalCommands returns the sorted names of the external commands found in
+// PATH, leaving out the names of the subcommands of the command.
+func (c *Command) externalCommands() []string {
+	if c.externalCommandPrefix == "" {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), c.externalCommandPrefix) {
+				continue
+			}
+			if _, err := exec.LookPath(filepath.Join(dir, entry.Name())); err != nil {
+				continue
+			}
+			name := strings.TrimPrefix(entry.Name(), c.externalCommandPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if name == "" || seen[name] || c.findNext(name) != nil {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
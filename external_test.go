@@ -0,0 +1,64 @@
+package zulu_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestEnableExternalCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell scripts are not executable on Windows")
+	}
+
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{
+		"root-hello":  0o755,
+		"root-child":  0o755,
+		"root-noexec": 0o644,
+		"other-hey":   0o755,
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\necho \"hello $*\"\n"), mode)
+		testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", Short: "child short", RunE: noopRun})
+	rootCmd.EnableExternalCommands("")
+
+	output, err := executeCommand(rootCmd, "hello", "--flag", "arg")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "hello --flag arg\n", output)
+
+	// Names with a path separator are not resolved relatively to the current directory
+	err = os.Mkdir(filepath.Join(dir, "root-sub"), 0o755)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	err = os.WriteFile(filepath.Join(dir, "root-sub", "hello"), []byte("#!/bin/sh\necho sub\n"), 0o755)
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	wd, err := os.Getwd()
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertNilf(t, os.Chdir(dir), "Unexpected error changing directory")
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	output, err = executeCommand(rootCmd, "sub/hello")
+	testutil.AssertNotNilf(t, err, "Expected an error")
+	testutil.AssertContains(t, output, `unknown command "sub/hello" for "root"`)
+
+	output, err = executeCommand(rootCmd, "noexec")
+	testutil.AssertNotNilf(t, err, "Expected an error")
+	testutil.AssertContains(t, output, `unknown command "noexec" for "root"`)
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	expected := "child\tchild short\n" +
+		"completion\tGenerate the autocompletion script for the specified shell\n" +
+		"help\tHelp about any command\n" +
+		"hello\n" +
+		":4\n" +
+		"Completion ended with directive: ShellCompDirectiveNoFileComp\n"
+	testutil.AssertEqual(t, expected, output)
+}
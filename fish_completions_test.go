@@ -3,6 +3,7 @@ package zulu_test
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -44,6 +45,28 @@ func TestCompleteCmdInFishScript(t *testing.T) {
 	testutil.AssertNotContains(t, output, zulu.ShellCompNoDescRequestCmd)
 }
 
+func TestFishScriptHandlesAllDirectives(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	buf := new(bytes.Buffer)
+	testutil.AssertNil(t, rootCmd.GenFishCompletion(buf, true))
+	output := buf.String()
+
+	for _, directive := range []zulu.ShellCompDirective{
+		zulu.ShellCompDirectiveError,
+		zulu.ShellCompDirectiveNoSpace,
+		zulu.ShellCompDirectiveNoFileComp,
+		zulu.ShellCompDirectiveFilterFileExt,
+		zulu.ShellCompDirectiveFilterDirs,
+		zulu.ShellCompDirectiveKeepOrder,
+	} {
+		testutil.AssertContains(t, output, fmt.Sprintf(" %d\n", directive))
+	}
+
+	testutil.AssertNotContains(t, output, "filtering not supported")
+	testutil.AssertContains(t, output, `for path in $prefix*/`)
+	testutil.AssertContains(t, output, `if string match -q -- "*.$ext" "$path"`)
+}
+
 func TestProgWithDash(t *testing.T) {
 	rootCmd := &zulu.Command{Use: "root-dash", Args: zulu.NoArgs, RunE: noopRun}
 	buf := new(bytes.Buffer)
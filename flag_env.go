@@ -0,0 +1,90 @@
+package zulu
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zulucmd/zflag/v2"
+)
+
+// FlagEnvAnnotation is the annotation holding the name of the environment
+// variable a flag is bound to.
+const FlagEnvAnnotation = "zulu_annotation_flag_env"
+
+// FlagOptEnv binds the flag to the environment variable with the given name.
+// When the flag is not set on the command line and the variable is set, the
+// flag takes the value of the variable after the flags are parsed, and is
+// marked as changed. Flags set on the command line therefore take precedence
+// over the environment, which takes precedence over the defaults and the
+// values of the config flag. During shell completion, a flag whose environment
+// variable is set is considered as set, so it is not suggested again, even if
+// it is required.
+func FlagOptEnv(name string) zflag.Opt {
+	return zflag.OptAnnotation(FlagEnvAnnotation, []string{name})
+}
+
+// BindFlagsToEnv binds each flag of the command and its children to an
+// environment variable named prefix, an underscore and the normalized flag
+// name, uppercased with its dashes replaced by underscores, e.g. --some-flag
+// to PREFIX_SOME_FLAG, as if FlagOptEnv was used. The flags using FlagOptEnv
+// keep their own variable, and the flags added by zulu, like --help, are not
+// bound.
+func (c *Command) BindFlagsToEnv(prefix string) {
+	c.flagEnvPrefix = prefix
+}
+
+// flagEnvName returns the name of the environment variable the flag is bound
+// to, or an empty string.
+func (c *Command) flagEnvName(flag *zflag.Flag) string {
+	if names := flag.Annotations[FlagEnvAnnotation]; len(names) > 0 {
+		return names[0]
+	}
+	if len(flag.Annotations[FlagSetByZuluAnnotation]) > 0 {
+		return ""
+	}
+
+	for p := c; p != nil; p = p.Parent() {
+		if p.flagEnvPrefix == "" {
+			continue
+		}
+		fs := c.Flags()
+		name := string(fs.GetNormalizeFunc()(fs, flag.Name))
+		return p.flagEnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	}
+	return ""
+}
+
+// flagSetFromEnv returns true if the flag is bound to an environment variable
+// that is set.
+func (c *Command) flagSetFromEnv(flag *zflag.Flag) bool {
+	name := c.flagEnvName(flag)
+	if name == "" {
+		return false
+	}
+
+	_, ok := os.LookupEnv(name)
+	return ok
+}
+
+// applyFlagEnv sets the flags of the command which are bound to an environment
+// variable that is set, unless they have been changed on the command line.
+func (c *Command) applyFlagEnv() error {
+	var err error
+	c.Flags().VisitAll(func(flag *zflag.Flag) {
+		name := c.flagEnvName(flag)
+		if err != nil || flag.Changed || name == "" {
+			return
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+
+		if setErr := c.Flags().Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for environment variable %s of flag %q: %w", value, name, flag.Name, setErr)
+		}
+	})
+	return err
+}
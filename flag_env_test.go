@@ -0,0 +1,161 @@
+package zulu_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestFlagOptEnvRequiredFlagCompletion(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().String("token", "", "api token", zflag.OptRequired(), zulu.FlagOptEnv("ZULU_TEST_TOKEN"))
+	rootCmd.Flags().String("region", "", "region", zflag.OptRequired())
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"--region",
+		"--token",
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	t.Setenv("ZULU_TEST_TOKEN", "secret")
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"--region",
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	// The flag is not suggested again either, as if it was set on the command-line.
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "--region", "eu", "--t")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
+
+func TestFlagOptEnv(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		token    string
+		count    int
+		changed  bool
+		errorMsg string
+	}{
+		{name: "default", args: []string{"child", "--count", "1"}, token: "default", count: 1},
+		{
+			name:    "env",
+			env:     map[string]string{"ZULU_TEST_TOKEN": "env", "ZULU_TEST_COUNT": "2"},
+			args:    []string{"child"},
+			token:   "env",
+			count:   2,
+			changed: true,
+		},
+		{
+			name:    "flag over env",
+			env:     map[string]string{"ZULU_TEST_TOKEN": "env", "ZULU_TEST_COUNT": "2"},
+			args:    []string{"child", "--token", "flag", "--count", "3"},
+			token:   "flag",
+			count:   3,
+			changed: true,
+		},
+		{
+			name:     "invalid env",
+			env:      map[string]string{"ZULU_TEST_COUNT": "two"},
+			args:     []string{"child"},
+			errorMsg: `invalid value "two" for environment variable ZULU_TEST_COUNT of flag "count"`,
+		},
+		{name: "required", args: []string{"child"}, errorMsg: `required flag(s) "--count" not set`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			var token string
+			var count int
+			rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+			rootCmd.PersistentFlags().StringVar(&token, "token", "default", "api token", zulu.FlagOptEnv("ZULU_TEST_TOKEN"))
+			childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+			childCmd.Flags().IntVar(&count, "count", 0, "count", zflag.OptRequired(), zulu.FlagOptEnv("ZULU_TEST_COUNT"))
+			rootCmd.AddCommand(childCmd)
+
+			_, err := executeCommand(rootCmd, tc.args...)
+			if tc.errorMsg != "" {
+				testutil.AssertErrf(t, err, "Expected an error")
+				testutil.AssertContains(t, err.Error(), tc.errorMsg)
+				return
+			}
+			testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+			testutil.AssertEqual(t, tc.token, token)
+			testutil.AssertEqual(t, tc.count, count)
+			testutil.AssertEqual(t, tc.changed, childCmd.Flags().Changed("token"))
+		})
+	}
+}
+
+func TestBindFlagsToEnv(t *testing.T) {
+	t.Setenv("ZULU_TEST_SOME_FLAG", "env")
+	t.Setenv("ZULU_TEST_OTHER_FLAG", "other")
+	t.Setenv("ZULU_TEST_COUNT", "2")
+	t.Setenv("ZULU_TEST_TOKEN", "ignored")
+	t.Setenv("ZULU_TEST_OWN_TOKEN", "own")
+	t.Setenv("ZULU_TEST_HELP", "true")
+
+	var someFlag, otherFlag, token string
+	var count int
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.SetGlobalNormalizationFunc(func(_ *zflag.FlagSet, name string) zflag.NormalizedName {
+		return zflag.NormalizedName(strings.ReplaceAll(name, "_", "-"))
+	})
+	rootCmd.PersistentFlags().StringVar(&someFlag, "some-flag", "default", "some flag")
+	rootCmd.PersistentFlags().StringVar(&token, "token", "", "api token", zulu.FlagOptEnv("ZULU_TEST_OWN_TOKEN"))
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().StringVar(&otherFlag, "other_flag", "", "other flag")
+	childCmd.Flags().IntVar(&count, "count", 0, "count")
+	rootCmd.AddCommand(childCmd)
+	rootCmd.BindFlagsToEnv("ZULU_TEST")
+
+	output, err := executeCommand(rootCmd, "child")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "", output)
+	testutil.AssertEqual(t, "env", someFlag)
+	testutil.AssertEqual(t, "other", otherFlag)
+	testutil.AssertEqual(t, 2, count)
+	testutil.AssertEqual(t, "own", token)
+	testutil.AssertEqual(t, true, childCmd.Flags().Changed("some-flag"))
+}
+
+func TestBindFlagsToEnvCommandLinePrecedence(t *testing.T) {
+	t.Setenv("ZULU_TEST_SOME_FLAG", "env")
+	t.Setenv("ZULU_TEST_COUNT", "2")
+
+	var someFlag string
+	var count int
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().StringVar(&someFlag, "some-flag", "default", "some flag")
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().IntVar(&count, "count", 0, "count")
+	rootCmd.AddCommand(childCmd)
+	rootCmd.BindFlagsToEnv("ZULU_TEST")
+
+	_, err := executeCommand(rootCmd, "child", "--some-flag", "flag", "--count", "3")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "flag", someFlag)
+	testutil.AssertEqual(t, 3, count)
+}
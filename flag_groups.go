@@ -2,10 +2,51 @@ package zulu
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/zulucmd/zflag/v2"
 )
 
+// The kinds of relationships between flags, see FlagGroupRelation.
+const (
+	FlagGroupRequiredTogether   = "required_together"
+	FlagGroupMutuallyExclusive  = "mutually_exclusive"
+	FlagGroupOneRequired        = "one_required"
+	FlagGroupExactlyOneRequired = "exactly_one_required"
+)
+
+// FlagGroupRelation is a relationship between flags created by one of the
+// MarkFlags methods of a command, e.g. to document it.
+type FlagGroupRelation struct {
+	// Kind is the kind of the relationship, one of the FlagGroup constants.
+	Kind string
+	// FlagNames are the names of the flags in the relationship.
+	FlagNames []string
+}
+
+// String describes the relationship in a sentence, e.g. "The flags --user, --password
+// must be used together.".
+func (r FlagGroupRelation) String() string {
+	dashed := make([]string, 0, len(r.FlagNames))
+	for _, name := range r.FlagNames {
+		dashed = append(dashed, "--"+name)
+	}
+	flags := strings.Join(dashed, ", ")
+
+	switch r.Kind {
+	case FlagGroupRequiredTogether:
+		return fmt.Sprintf("The flags %s must be used together.", flags)
+	case FlagGroupMutuallyExclusive:
+		return fmt.Sprintf("The flags %s are mutually exclusive.", flags)
+	case FlagGroupOneRequired:
+		return fmt.Sprintf("At least one of the flags %s is required.", flags)
+	case FlagGroupExactlyOneRequired:
+		return fmt.Sprintf("Exactly one of the flags %s is required.", flags)
+	default:
+		return fmt.Sprintf("The flags %s are related.", flags)
+	}
+}
+
 // MarkFlagsRequiredTogether creates a relationship between flags, which ensures
 // that if any of flags with names from flagNames is set, other flags must be set too.
 func (c *Command) MarkFlagsRequiredTogether(flagNames ...string) {
@@ -22,13 +63,32 @@ func (c *Command) MarkFlagsMutuallyExclusive(flagNames ...string) {
 	})
 }
 
+// MarkFlagsOneRequired creates a relationship between flags, which ensures
+// that at least one of flags with names from flagNames is set.
+func (c *Command) MarkFlagsOneRequired(flagNames ...string) {
+	c.addFlagGroup(&oneRequiredFlagGroup{
+		flagNames: flagNames,
+	})
+}
+
+// MarkFlagsMutuallyExclusiveAndOneRequired creates a relationship between flags,
+// which ensures that exactly one of flags with names from flagNames is set.
+func (c *Command) MarkFlagsMutuallyExclusiveAndOneRequired(flagNames ...string) {
+	c.addFlagGroup(&exactlyOneRequiredFlagGroup{
+		flagNames: flagNames,
+	})
+}
+
 // addFlagGroup merges persistent flags of the command and adds flagGroup into command's flagGroups list.
 // Panics, if flagGroup g contains the name of the flag, which is not defined in the Command c.
+// As the flags inherited from the parents of c can only be looked up once c has been added
+// to them, the flags which cannot be found yet are only checked by ValidateFlagGroups if c
+// has no parent yet.
 func (c *Command) addFlagGroup(g flagGroup) {
 	c.mergePersistentFlags()
 
 	for _, flagName := range g.AssignedFlagNames() {
-		if c.Flags().Lookup(flagName) == nil {
+		if c.Flags().Lookup(flagName) == nil && c.HasParent() {
 			panic(fmt.Sprintf("flag %q is not defined", flagName))
 		}
 	}
@@ -36,11 +96,53 @@ func (c *Command) addFlagGroup(g flagGroup) {
 	c.flagGroups = append(c.flagGroups, g)
 }
 
-// validateFlagGroups runs validation for each group from command's flagGroups list,
+// checkFlagGroup merges persistent flags of the command, including those of its parents
+// at any depth, and returns an error if flagGroup g contains the name of a flag which is
+// not defined.
+func (c *Command) checkFlagGroup(g flagGroup) error {
+	c.mergePersistentFlags()
+
+	for _, flagName := range g.AssignedFlagNames() {
+		if c.Flags().Lookup(flagName) == nil {
+			return fmt.Errorf("flag %q is not defined", flagName)
+		}
+	}
+	return nil
+}
+
+// FlagGroupRelations returns the relationships between flags created with the
+// MarkFlags methods of the command, in the order they were created. As they
+// are meant to be documented, the hidden flags are left out, and so are the
+// relationships left with less than two flags.
+func (c *Command) FlagGroupRelations() []FlagGroupRelation {
+	c.mergePersistentFlags()
+
+	relations := make([]FlagGroupRelation, 0, len(c.flagGroups))
+	for _, group := range c.flagGroups {
+		var names []string
+		for _, name := range group.AssignedFlagNames() {
+			if flag := c.Flags().Lookup(name); flag == nil || !flag.Hidden {
+				names = append(names, name)
+			}
+		}
+		if len(names) < 2 {
+			continue
+		}
+		relations = append(relations, FlagGroupRelation{Kind: group.Kind(), FlagNames: names})
+	}
+	return relations
+}
+
+// ValidateFlagGroups runs validation for each group from command's flagGroups list,
 // and returns the first error encountered, or nil, if there were no validation errors.
-func (c *Command) validateFlagGroups() error {
+// It is run before the RunE-style functions of the command, and can also be called
+// once the flags are parsed, e.g. with ParseFlags, to check them without executing it.
+func (c *Command) ValidateFlagGroups() error {
 	setFlags := makeSetFlagsSet(c.Flags())
 	for _, group := range c.flagGroups {
+		if err := c.checkFlagGroup(group); err != nil {
+			return err
+		}
 		if err := group.ValidateSetFlags(setFlags); err != nil {
 			return err
 		}
@@ -50,13 +152,16 @@ func (c *Command) validateFlagGroups() error {
 
 // adjustByFlagGroupsForCompletions changes the command by each flagGroup from command's flagGroups list
 // to make the further command completions generation more convenient.
-// Does nothing, if Command.DisableFlagParsing is true.
+// Does nothing, if Command.DisableFlagParsing is true, and skips the groups with undefined flags.
 func (c *Command) adjustByFlagGroupsForCompletions() {
 	if c.DisableFlagParsing {
 		return
 	}
 
 	for _, group := range c.flagGroups {
+		if c.checkFlagGroup(group) != nil {
+			continue
+		}
 		group.AdjustCommandForCompletions(c)
 	}
 }
@@ -69,6 +174,9 @@ type flagGroup interface {
 	// AssignedFlagNames returns a full list of flag names that have been assigned to the group.
 	AssignedFlagNames() []string
 
+	// Kind returns the kind of the group, one of the FlagGroup constants.
+	Kind() string
+
 	// AdjustCommandForCompletions updates the command to generate more convenient for this group completions.
 	AdjustCommandForCompletions(c *Command)
 }
@@ -82,6 +190,9 @@ type requiredTogetherFlagGroup struct {
 func (g *requiredTogetherFlagGroup) AssignedFlagNames() []string {
 	return g.flagNames
 }
+func (g *requiredTogetherFlagGroup) Kind() string {
+	return FlagGroupRequiredTogether
+}
 func (g *requiredTogetherFlagGroup) ValidateSetFlags(setFlags setFlagsSet) error {
 	unset := setFlags.selectUnsetFlagNamesFrom(g.flagNames)
 
@@ -94,10 +205,7 @@ func (g *requiredTogetherFlagGroup) ValidateSetFlags(setFlags setFlagsSet) error
 func (g *requiredTogetherFlagGroup) AdjustCommandForCompletions(c *Command) {
 	setFlags := makeSetFlagsSet(c.Flags())
 	if setFlags.hasAnyFrom(g.flagNames) {
-		for _, requiredFlagName := range g.flagNames {
-			f := c.Flags().Lookup(requiredFlagName)
-			_ = zflag.OptRequired()(f)
-		}
+		markFlagsRequiredForCompletions(c, g.flagNames)
 	}
 }
 
@@ -110,6 +218,9 @@ type mutuallyExclusiveFlagGroup struct {
 func (g *mutuallyExclusiveFlagGroup) AssignedFlagNames() []string {
 	return g.flagNames
 }
+func (g *mutuallyExclusiveFlagGroup) Kind() string {
+	return FlagGroupMutuallyExclusive
+}
 func (g *mutuallyExclusiveFlagGroup) ValidateSetFlags(setFlags setFlagsSet) error {
 	set := setFlags.selectSetFlagNamesFrom(g.flagNames)
 
@@ -130,6 +241,71 @@ func (g *mutuallyExclusiveFlagGroup) AdjustCommandForCompletions(c *Command) {
 	}
 }
 
+// oneRequiredFlagGroup groups flags of which at least one must be set.
+type oneRequiredFlagGroup struct {
+	flagNames []string
+}
+
+func (g *oneRequiredFlagGroup) AssignedFlagNames() []string {
+	return g.flagNames
+}
+func (g *oneRequiredFlagGroup) Kind() string {
+	return FlagGroupOneRequired
+}
+func (g *oneRequiredFlagGroup) ValidateSetFlags(setFlags setFlagsSet) error {
+	if !setFlags.hasAnyFrom(g.flagNames) {
+		return fmt.Errorf("at least one of the flags %v is required", g.flagNames)
+	}
+	return nil
+}
+func (g *oneRequiredFlagGroup) AdjustCommandForCompletions(c *Command) {
+	setFlags := makeSetFlagsSet(c.Flags())
+	if !setFlags.hasAnyFrom(g.flagNames) {
+		markFlagsRequiredForCompletions(c, g.flagNames)
+	}
+}
+
+// exactlyOneRequiredFlagGroup groups flags that are mutually exclusive
+// and of which one must be set.
+type exactlyOneRequiredFlagGroup struct {
+	flagNames []string
+}
+
+func (g *exactlyOneRequiredFlagGroup) AssignedFlagNames() []string {
+	return g.flagNames
+}
+func (g *exactlyOneRequiredFlagGroup) Kind() string {
+	return FlagGroupExactlyOneRequired
+}
+func (g *exactlyOneRequiredFlagGroup) ValidateSetFlags(setFlags setFlagsSet) error {
+	set := setFlags.selectSetFlagNamesFrom(g.flagNames)
+
+	if len(set) == 0 {
+		return fmt.Errorf("exactly one of the flags %v is required, but none were set", g.flagNames)
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("exactly one of the flags %v can be set, but %v were set", g.flagNames, set)
+	}
+	return nil
+}
+func (g *exactlyOneRequiredFlagGroup) AdjustCommandForCompletions(c *Command) {
+	(&oneRequiredFlagGroup{flagNames: g.flagNames}).AdjustCommandForCompletions(c)
+	(&mutuallyExclusiveFlagGroup{flagNames: g.flagNames}).AdjustCommandForCompletions(c)
+}
+
+// markFlagsRequiredForCompletions marks the flags of the command with names from
+// flagNames as required, so that they are completed first.
+func markFlagsRequiredForCompletions(c *Command, flagNames []string) {
+	for _, requiredFlagName := range flagNames {
+		f := c.Flags().Lookup(requiredFlagName)
+		// Hidden or deprecated flags must never be suggested, even when required.
+		if nonCompletableFlag(f) {
+			continue
+		}
+		_ = zflag.OptRequired()(f)
+	}
+}
+
 // setFlagsSet is a helper set type that is intended to be used to store names of the flags
 // that have been set in flag.FlagSet and to perform some lookups and checks on those flags.
 type setFlagsSet map[string]struct{}
@@ -138,9 +314,13 @@ type setFlagsSet map[string]struct{}
 func makeSetFlagsSet(fs *zflag.FlagSet) setFlagsSet {
 	s := make(setFlagsSet)
 
-	// Visit flags that have been set and add them to the set
-	fs.Visit(func(f *zflag.Flag) {
-		s[f.Name] = struct{}{}
+	// Visit flags that have been set and add them to the set. Flags inherited from parents
+	// may have been set while parsing the flags of a parent, e.g. with TraverseChildren,
+	// so they are not known as set by fs.Visit.
+	fs.VisitAll(func(f *zflag.Flag) {
+		if f.Changed {
+			s[f.Name] = struct{}{}
+		}
 	})
 
 	return s
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/zulucmd/zflag/v2"
 	"github.com/zulucmd/zulu/v2"
 	"github.com/zulucmd/zulu/v2/internal/testutil"
 )
@@ -15,6 +16,8 @@ func TestValidateFlagGroups(t *testing.T) {
 		desc                 string
 		requiredTogether     []string
 		mutuallyExclusive    []string
+		oneRequired          []string
+		exactlyOneRequired   []string
 		subRequiredTogether  []string
 		subMutuallyExclusive []string
 		args                 []string
@@ -50,6 +53,40 @@ func TestValidateFlagGroups(t *testing.T) {
 			mutuallyExclusive: []string{"a b c"},
 			args:              []string{"--b=foo"},
 		},
+		{
+			desc:        "One required flag group validation fails",
+			oneRequired: []string{"a b c"},
+			args:        []string{"--d=foo"},
+			expectErr:   `at least one of the flags [a b c] is required`,
+		},
+		{
+			desc:        "One required flag group validation passes",
+			oneRequired: []string{"a b c"},
+			args:        []string{"--b=foo", "--c=bar"},
+		},
+		{
+			desc:              "One required and mutually exclusive flag groups pass with a single flag",
+			oneRequired:       []string{"a b"},
+			mutuallyExclusive: []string{"a b"},
+			args:              []string{"--a=foo"},
+		},
+		{
+			desc:               "Mutually exclusive and one required flag group validation fails when none set",
+			exactlyOneRequired: []string{"a b c"},
+			args:               []string{"--d=foo"},
+			expectErr:          `exactly one of the flags [a b c] is required, but none were set`,
+		},
+		{
+			desc:               "Mutually exclusive and one required flag group validation fails when many set",
+			exactlyOneRequired: []string{"a b c"},
+			args:               []string{"--a=foo", "--c=bar"},
+			expectErr:          `exactly one of the flags [a b c] can be set, but [a c] were set`,
+		},
+		{
+			desc:               "Mutually exclusive and one required flag group validation passes",
+			exactlyOneRequired: []string{"a b c"},
+			args:               []string{"--b=foo"},
+		},
 		{
 			desc:             "Multiple required together flag groups failed validation returns first error",
 			requiredTogether: []string{"a b c", "a d"},
@@ -142,6 +179,12 @@ func TestValidateFlagGroups(t *testing.T) {
 			for _, group := range tc.mutuallyExclusive {
 				cmd.MarkFlagsMutuallyExclusive(strings.Split(group, " ")...)
 			}
+			for _, group := range tc.oneRequired {
+				cmd.MarkFlagsOneRequired(strings.Split(group, " ")...)
+			}
+			for _, group := range tc.exactlyOneRequired {
+				cmd.MarkFlagsMutuallyExclusiveAndOneRequired(strings.Split(group, " ")...)
+			}
 			for _, group := range tc.subRequiredTogether {
 				subCmd.MarkFlagsRequiredTogether(strings.Split(group, " ")...)
 			}
@@ -162,3 +205,232 @@ func TestValidateFlagGroups(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateFlagGroupsWithoutExecuting(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().String("config", "", "config file")
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().String("a", "", "")
+	childCmd.Flags().String("b", "", "")
+	rootCmd.AddCommand(childCmd)
+	childCmd.MarkFlagsRequiredTogether("a", "config")
+	childCmd.MarkFlagsMutuallyExclusive("a", "b")
+
+	testutil.AssertNilf(t, childCmd.ParseFlags([]string{"--a", "x", "--config", "c"}), "Unexpected error")
+	testutil.AssertNilf(t, childCmd.ValidateFlagGroups(), "Unexpected error")
+
+	testutil.AssertNilf(t, childCmd.Flags().Set("b", "y"), "Unexpected error")
+	err := childCmd.ValidateFlagGroups()
+	testutil.AssertNotNilf(t, err, "Expected an error when both exclusive flags are set")
+	testutil.AssertEqual(t, "exactly one of the flags [a b] can be set, but [a b] were set", err.Error())
+}
+
+func TestFlagGroupRelations(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: noopRun}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		cmd.Flags().Bool(name, false, name)
+	}
+	cmd.MarkFlagsRequiredTogether("a", "b")
+	cmd.MarkFlagsMutuallyExclusive("b", "c")
+	cmd.MarkFlagsOneRequired("c", "d")
+	cmd.MarkFlagsMutuallyExclusiveAndOneRequired("a", "d")
+
+	var descriptions []string
+	for _, relation := range cmd.FlagGroupRelations() {
+		descriptions = append(descriptions, relation.Kind+": "+relation.String())
+	}
+	expected := strings.Join([]string{
+		"required_together: The flags --a, --b must be used together.",
+		"mutually_exclusive: The flags --b, --c are mutually exclusive.",
+		"one_required: At least one of the flags --c, --d is required.",
+		"exactly_one_required: Exactly one of the flags --a, --d is required.",
+	}, "\n")
+	testutil.AssertEqual(t, expected, strings.Join(descriptions, "\n"))
+}
+
+func TestFlagGroupRelationsHiddenFlags(t *testing.T) {
+	cmd := &zulu.Command{Use: "root", RunE: noopRun}
+	cmd.PersistentFlags().Bool("debug", false, "debug", zflag.OptHidden())
+	for _, name := range []string{"a", "b", "c"} {
+		cmd.Flags().Bool(name, false, name)
+	}
+	cmd.MarkFlagsMutuallyExclusive("a", "b", "debug")
+	cmd.MarkFlagsRequiredTogether("c", "debug")
+
+	var descriptions []string
+	for _, relation := range cmd.FlagGroupRelations() {
+		descriptions = append(descriptions, relation.String())
+	}
+	testutil.AssertEqual(t, "The flags --a, --b are mutually exclusive.", strings.Join(descriptions, "\n"))
+}
+
+func TestHiddenFlagInRequiredTogetherGroup(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().String("visible", "", "visible flag")
+	rootCmd.Flags().String("secret", "", "secret flag", zflag.OptHidden())
+	rootCmd.MarkFlagsRequiredTogether("visible", "secret")
+
+	_, err := executeCommand(rootCmd, "--visible", "foo")
+	testutil.AssertNotNilf(t, err, "Expected an error when the hidden flag is unset")
+	testutil.AssertEqual(t, "flags [visible secret] must be set together, but [secret] were not set", err.Error())
+
+	_, err = executeCommand(rootCmd, "--visible", "foo", "--secret", "bar")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+}
+
+func TestHiddenFlagInRequiredTogetherGroupCompletions(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().String("visible", "", "visible flag")
+	rootCmd.Flags().String("secret", "", "secret flag", zflag.OptHidden())
+	rootCmd.MarkFlagsRequiredTogether("visible", "secret")
+
+	for _, args := range [][]string{
+		{"--visible", "foo", ""},
+		{"--visible", "foo", "-"},
+		{"--visible", "foo", "--s"},
+	} {
+		output, err := executeCommand(rootCmd, append([]string{zulu.ShellCompNoDescRequestCmd}, args...)...)
+		testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+		testutil.AssertNotContains(t, output, "--secret")
+	}
+}
+
+func TestFlagGroupsAcrossDepth(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		desc             string
+		traverseChildren bool
+		markBeforeAdd    bool
+		args             []string
+		expectErr        string
+	}{
+		{
+			desc: "Flags of all levels set",
+			args: []string{"child", "grandchild", "--p-a=foo", "--c-a=foo", "--g-a=foo"},
+		},
+		{
+			desc:      "Grandparent and parent flags not set",
+			args:      []string{"child", "grandchild", "--g-a=foo"},
+			expectErr: `flags [p-a c-a g-a] must be set together, but [p-a c-a] were not set`,
+		},
+		{
+			desc:          "Group marked before the command is added to its parents",
+			markBeforeAdd: true,
+			args:          []string{"child", "grandchild", "--p-a=foo", "--c-a=foo", "--g-a=foo"},
+		},
+		{
+			desc:          "Group marked before the command is added to its parents fails",
+			markBeforeAdd: true,
+			args:          []string{"child", "grandchild", "--c-a=foo"},
+			expectErr:     `flags [p-a c-a g-a] must be set together, but [p-a g-a] were not set`,
+		},
+		{
+			desc:             "Flags set on each level while traversing children",
+			traverseChildren: true,
+			args:             []string{"--p-a=foo", "child", "--c-a=foo", "grandchild", "--g-a=foo"},
+		},
+		{
+			desc:             "Flags set on parents while traversing children fail",
+			traverseChildren: true,
+			args:             []string{"--p-a=foo", "child", "grandchild"},
+			expectErr:        `flags [p-a c-a g-a] must be set together, but [c-a g-a] were not set`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			rootCmd := &zulu.Command{Use: "root", RunE: noopRun, TraverseChildren: tc.traverseChildren}
+			childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+			grandchildCmd := &zulu.Command{Use: "grandchild", RunE: noopRun}
+
+			rootCmd.PersistentFlags().String("p-a", "", "")
+			childCmd.PersistentFlags().String("c-a", "", "")
+			grandchildCmd.Flags().String("g-a", "", "")
+
+			if tc.markBeforeAdd {
+				grandchildCmd.MarkFlagsRequiredTogether("p-a", "c-a", "g-a")
+			}
+			childCmd.AddCommand(grandchildCmd)
+			rootCmd.AddCommand(childCmd)
+			if !tc.markBeforeAdd {
+				grandchildCmd.MarkFlagsRequiredTogether("p-a", "c-a", "g-a")
+			}
+
+			_, err := executeCommand(rootCmd, tc.args...)
+
+			if len(tc.expectErr) > 0 {
+				testutil.AssertNotNilf(t, err, "Expected an error")
+				testutil.AssertEqual(t, tc.expectErr, err.Error())
+				return
+			}
+
+			testutil.AssertNilf(t, err, "Unexpected error")
+		})
+	}
+}
+
+func TestFlagGroupUndefinedFlag(t *testing.T) {
+	t.Parallel()
+
+	assertPanics := func(t *testing.T, f func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected a panic for an undefined flag")
+			}
+		}()
+		f()
+	}
+
+	t.Run("Command with a parent", func(t *testing.T) {
+		t.Parallel()
+
+		rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+		childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+		rootCmd.AddCommand(childCmd)
+		childCmd.Flags().String("a", "", "")
+
+		assertPanics(t, func() { childCmd.MarkFlagsRequiredTogether("a", "typo") })
+	})
+
+	t.Run("Command without a parent", func(t *testing.T) {
+		t.Parallel()
+
+		rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+		rootCmd.Flags().String("a", "", "")
+		rootCmd.MarkFlagsRequiredTogether("a", "typo")
+
+		_, err := executeCommand(rootCmd, "--a=foo")
+		testutil.AssertErrf(t, err, "Expected an error for an undefined flag")
+		testutil.AssertEqual(t, `flag "typo" is not defined`, err.Error())
+		err = rootCmd.ValidateFlagGroups()
+		testutil.AssertErrf(t, err, "Expected an error for an undefined flag")
+		testutil.AssertEqual(t, `flag "typo" is not defined`, err.Error())
+	})
+}
+
+func TestCompletionForFlagGroupsAcrossDepth(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	grandchildCmd := &zulu.Command{Use: "grandchild", RunE: noopRun}
+	childCmd.AddCommand(grandchildCmd)
+	rootCmd.AddCommand(childCmd)
+
+	rootCmd.PersistentFlags().String("p-a", "", "")
+	childCmd.PersistentFlags().String("c-a", "", "")
+	grandchildCmd.Flags().String("g-a", "", "")
+	grandchildCmd.MarkFlagsRequiredTogether("p-a", "c-a", "g-a")
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "child", "grandchild", "--g-a", "foo", "")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"--c-a",
+		"--p-a",
+		":0",
+		"Completion ended with directive: ShellCompDirectiveDefault", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
@@ -0,0 +1,59 @@
+package zulu
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zulucmd/zflag/v2"
+)
+
+// FlagMaxOccurrencesAnnotation is the annotation holding the maximum number of
+// times a flag can be set on the command line.
+const FlagMaxOccurrencesAnnotation = "zulu_annotation_flag_max_occurrences"
+
+// FlagOptMaxOccurrences limits the number of times the flag can be set on the
+// command line to n, e.g. for a slice flag that can be repeated. Setting it more
+// often is an error, and shell completion stops suggesting the flag once it has
+// been set n times.
+func FlagOptMaxOccurrences(n int) zflag.Opt {
+	return zflag.OptAnnotation(FlagMaxOccurrencesAnnotation, []string{strconv.Itoa(n)})
+}
+
+// flagMaxOccurrences returns the maximum number of times the flag can be set,
+// if it has one.
+func flagMaxOccurrences(flag *zflag.Flag) (int, bool) {
+	values := flag.Annotations[FlagMaxOccurrencesAnnotation]
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// maxOccurrencesReached returns true if the flag has been set on the command
+// line as many times as it can be.
+func (c *Command) maxOccurrencesReached(flag *zflag.Flag) bool {
+	n, ok := flagMaxOccurrences(flag)
+	return ok && c.flagOccurrences[flag.Name] >= n
+}
+
+// validateFlagOccurrences returns an error if a flag has been set on the
+// command line more times than it can be.
+func (c *Command) validateFlagOccurrences() error {
+	var err error
+	c.Flags().VisitAll(func(flag *zflag.Flag) {
+		n, ok := flagMaxOccurrences(flag)
+		if !ok || err != nil {
+			return
+		}
+
+		if occurrences := c.flagOccurrences[flag.Name]; occurrences > n {
+			err = fmt.Errorf("flag %q can be set at most %d times, but was set %d times", flag.Name, n, occurrences)
+		}
+	})
+	return err
+}
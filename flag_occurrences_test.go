@@ -0,0 +1,46 @@
+package zulu_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestFlagOptMaxOccurrences(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().StringSlice("tag", nil, "tag", zflag.OptShorthand('t'), zulu.FlagOptMaxOccurrences(2))
+
+	_, err := executeCommand(rootCmd, "--tag", "a", "-t", "b")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	_, err = executeCommand(rootCmd, "--tag", "a", "-t", "b", "--tag=c")
+	testutil.AssertErrf(t, err, "Expected an error when the flag is set too many times")
+	testutil.AssertEqual(t, `flag "tag" can be set at most 2 times, but was set 3 times`, err.Error())
+}
+
+func TestFlagOptMaxOccurrencesCompletion(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().StringSlice("tag", nil, "tag", zulu.FlagOptMaxOccurrences(2))
+
+	output, err := executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "--tag", "a", "--")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := strings.Join([]string{
+		"--help",
+		"--tag",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+
+	output, err = executeCommand(rootCmd, zulu.ShellCompNoDescRequestCmd, "--tag", "a", "--tag", "b", "--")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected = strings.Join([]string{
+		"--help",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	testutil.AssertEqual(t, expected, output)
+}
@@ -0,0 +1,58 @@
+package zulu
+
+import (
+	"fmt"
+
+	"github.com/zulucmd/zflag/v2"
+)
+
+// FlagSourceFn returns the value of the flag named flagName from a key/value
+// source, like a config file, and whether the source has one.
+type FlagSourceFn func(flagName string) (string, bool)
+
+// BindFlags adds a source of values for the flags of the command and its
+// children, like the config file of EnableConfigFlag. Once the flags are
+// parsed, each flag that is neither set on the command line nor through its
+// environment variable takes the value of the first source having one, the
+// sources of the command coming before the ones of its parents, and is marked
+// as changed, before the required flags are checked. The flags added by zulu, like
+// --help, are not bound.
+func (c *Command) BindFlags(source FlagSourceFn) {
+	c.flagSources = append(c.flagSources, source)
+}
+
+// applyFlagSources sets the flags of the command which have not been changed
+// from the flag sources of the command and its parents.
+func (c *Command) applyFlagSources() error {
+	if err := c.loadConfigFlags(); err != nil {
+		return err
+	}
+
+	var sources []FlagSourceFn
+	for p := c; p != nil; p = p.Parent() {
+		sources = append(sources, p.flagSources...)
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var err error
+	c.Flags().VisitAll(func(flag *zflag.Flag) {
+		if err != nil || flag.Changed || len(flag.Annotations[FlagSetByZuluAnnotation]) > 0 {
+			return
+		}
+
+		for _, source := range sources {
+			value, ok := source(flag.Name)
+			if !ok {
+				continue
+			}
+
+			if setErr := c.Flags().Set(flag.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for flag %q from its source: %w", value, flag.Name, setErr)
+			}
+			return
+		}
+	})
+	return err
+}
@@ -0,0 +1,78 @@
+package zulu_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestBindFlags(t *testing.T) {
+	mapSource := func(values map[string]string, queried *[]string) zulu.FlagSourceFn {
+		return func(flagName string) (string, bool) {
+			*queried = append(*queried, flagName)
+			value, ok := values[flagName]
+			return value, ok
+		}
+	}
+
+	var queried []string
+	var region, token, name string
+	var count int
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().StringVar(&region, "region", "default", "region")
+	rootCmd.PersistentFlags().StringVar(&token, "token", "", "api token", zulu.FlagOptEnv("ZULU_TEST_TOKEN"))
+	childCmd := &zulu.Command{Use: "child", RunE: noopRun}
+	childCmd.Flags().StringVar(&name, "name", "", "name", zflag.OptRequired())
+	childCmd.Flags().IntVar(&count, "count", 0, "count")
+	rootCmd.AddCommand(childCmd)
+
+	rootCmd.BindFlags(mapSource(map[string]string{"region": "root", "name": "root", "token": "root"}, &queried))
+	childCmd.BindFlags(mapSource(map[string]string{"name": "child"}, &queried))
+	t.Setenv("ZULU_TEST_TOKEN", "env")
+
+	_, err := executeCommand(rootCmd, "child", "--count", "3")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "root", region)
+	testutil.AssertEqual(t, "env", token)
+	testutil.AssertEqual(t, "child", name)
+	testutil.AssertEqual(t, 3, count)
+	testutil.AssertEqual(t, true, childCmd.Flags().Changed("name"))
+
+	// Neither the flags set on the command line or through the environment,
+	// nor the flags added by zulu are queried
+	testutil.AssertEqual(t, "name region region", strings.Join(queried, " "))
+
+	invalidCmd := &zulu.Command{Use: "invalid", RunE: noopRun}
+	invalidCmd.Flags().Int("count", 0, "count")
+	invalidCmd.BindFlags(mapSource(map[string]string{"count": "three"}, &queried))
+	_, err = executeCommand(invalidCmd)
+	testutil.AssertErrf(t, err, "Expected an error")
+	testutil.AssertContains(t, err.Error(), `invalid value "three" for flag "count" from its source`)
+}
+
+func TestBindFlagsWithConfigFlag(t *testing.T) {
+	var region, token, name string
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.Flags().StringVar(&region, "region", "default", "region")
+	rootCmd.Flags().StringVar(&token, "token", "", "api token", zulu.FlagOptEnv("ZULU_TEST_TOKEN"))
+	rootCmd.Flags().StringVar(&name, "name", "", "name", zflag.OptRequired())
+
+	// The config file is a source like the others, queried in the order the
+	// sources are added
+	rootCmd.EnableConfigFlag("config", func(path string) (map[string]string, error) {
+		return map[string]string{"region": "config", "token": "config"}, nil
+	})
+	rootCmd.BindFlags(func(flagName string) (string, bool) {
+		return "source", flagName != "token"
+	})
+	t.Setenv("ZULU_TEST_TOKEN", "env")
+
+	_, err := executeCommand(rootCmd, "--config", "config.yaml")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+	testutil.AssertEqual(t, "config", region)
+	testutil.AssertEqual(t, "env", token)
+	testutil.AssertEqual(t, "source", name)
+}
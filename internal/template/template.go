@@ -28,6 +28,13 @@ func ParseFromFile(fsys fs.FS, templateFile string, data any, funcs template.Fun
 	return buf.String(), nil
 }
 
+// Check parses the given template text with funcs, and returns an error if it
+// is malformed.
+func Check(text string, funcs template.FuncMap) error {
+	_, err := template.New("top").Funcs(funcs).Parse(text)
+	return err
+}
+
 // Parse executes the given template text on data, writing the result to w.
 func Parse(w io.Writer, text string, data any, funcs template.FuncMap) error {
 	t := template.New("top")
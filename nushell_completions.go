@@ -0,0 +1,23 @@
+package zulu
+
+import (
+	"io"
+	"os"
+)
+
+// GenNushellCompletionFile generates nushell completion file.
+func (c *Command) GenNushellCompletionFile(filename string, includeDesc bool) error {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return c.GenNushellCompletion(outFile, includeDesc)
+}
+
+// GenNushellCompletion generates nushell completion file and writes to the passed writer.
+// The script defines an external completer, which must be set in the nushell config.
+func (c *Command) GenNushellCompletion(w io.Writer, includeDesc bool) error {
+	return genTemplateCompletion(w, "templates/completion.nu.gotmpl", c.Name(), includeDesc)
+}
@@ -0,0 +1,69 @@
+package zulu_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestCompleteNoDesCmdInNushellScript(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	child := &zulu.Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		RunE:              noopRun,
+	}
+	rootCmd.AddCommand(child)
+
+	buf := new(bytes.Buffer)
+	testutil.AssertNil(t, rootCmd.GenNushellCompletion(buf, false))
+	output := buf.String()
+
+	testutil.AssertContains(t, output, zulu.ShellCompNoDescRequestCmd)
+}
+
+func TestCompleteCmdInNushellScript(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	child := &zulu.Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		RunE:              noopRun,
+	}
+	rootCmd.AddCommand(child)
+
+	buf := new(bytes.Buffer)
+	testutil.AssertNil(t, rootCmd.GenNushellCompletion(buf, true))
+	output := buf.String()
+
+	testutil.AssertContains(t, output, zulu.ShellCompRequestCmd+" ")
+	testutil.AssertNotContains(t, output, zulu.ShellCompNoDescRequestCmd)
+}
+
+func TestNushellProgWithDash(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root-dash", Args: zulu.NoArgs, RunE: noopRun}
+	buf := new(bytes.Buffer)
+	testutil.AssertNil(t, rootCmd.GenNushellCompletion(buf, false))
+	output := buf.String()
+
+	// Variable names should have replaced the '-'
+	testutil.AssertContains(t, output, "let root_dash_completer")
+	testutil.AssertNotContains(t, output, "let root-dash_completer")
+
+	// The command name should not have replaced the '-'
+	testutil.AssertContains(t, output, `!= "root-dash"`)
+}
+
+func TestGenNushellCompletionFile(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", Args: zulu.NoArgs, RunE: noopRun}
+	rootCmd.AddCommand(&zulu.Command{Use: "child", RunE: noopRun})
+
+	filename := filepath.Join(t.TempDir(), "completion.nu")
+	testutil.AssertNil(t, rootCmd.GenNushellCompletionFile(filename, false))
+
+	_, err := os.Stat(filename)
+	testutil.AssertNil(t, err)
+}
@@ -33,3 +33,32 @@ func FlagOptCompletionFunc(f FlagCompletionFn) zflag.Opt {
 		return nil
 	}
 }
+
+// RegisterFlagCompletionFunc registers f to provide completion for the flag
+// flagName of the command, which can be a local, persistent or inherited flag.
+// It is useful for flags which are created without FlagOptCompletionFunc, e.g.
+// when they are built dynamically.
+func (c *Command) RegisterFlagCompletionFunc(flagName string, f FlagCompletionFn) error {
+	flag := c.Flag(flagName)
+	if flag == nil {
+		return fmt.Errorf("RegisterFlagCompletionFunc: flag '%s' does not exist", flagName)
+	}
+
+	return FlagOptCompletionFunc(f)(flag)
+}
+
+// GetFlagCompletionFunc returns the completion function registered for the flag
+// flagName of the command, and whether there is one. This allows, for example,
+// wrapping the function with extra filtering.
+func (c *Command) GetFlagCompletionFunc(flagName string) (FlagCompletionFn, bool) {
+	flag := c.Flag(flagName)
+	if flag == nil {
+		return nil, false
+	}
+
+	flagCompletionMutex.RLock()
+	defer flagCompletionMutex.RUnlock()
+
+	completionFn, exists := flagCompletionFunctions[flag]
+	return completionFn, exists
+}
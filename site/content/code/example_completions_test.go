@@ -0,0 +1 @@
+../../../example_completions_test.go
\ No newline at end of file
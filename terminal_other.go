@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package zulu
+
+import "os"
+
+// terminalColumns returns the number of columns of the terminal f, which is
+// not supported on this platform, so it always returns 0.
+func terminalColumns(*os.File) int {
+	return 0
+}
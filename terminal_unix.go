@@ -0,0 +1,28 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package zulu
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalColumns returns the number of columns of the terminal f, or 0 if f
+// is not a terminal.
+func terminalColumns(f *os.File) int {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		f.Fd(),
+		uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(&ws)), //nolint:gosec // required by the ioctl
+	)
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
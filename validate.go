@@ -0,0 +1,149 @@
+package zulu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zulucmd/zflag/v2"
+)
+
+// Validate checks the command tree rooted at c for configuration mistakes,
+// and returns all problems found joined into a single error, or nil if there
+// were none.
+//
+// Currently, the examples of all commands that set VerifyExamples are checked
+// against the flags the commands actually define.
+func (c *Command) Validate() error {
+	var errs []error
+
+	if c.VerifyExamples {
+		errs = append(errs, c.verifyExamples()...)
+	}
+
+	for _, cmd := range c.commands {
+		if err := cmd.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// verifyExamples parses each line of the Example that invokes the program,
+// and returns an error for each unknown command or flag referenced in them.
+// Lines that do not start with the name of the root command are ignored, so
+// that examples can contain comments and other prose.
+func (c *Command) verifyExamples() []error {
+	var errs []error
+
+	root := c.Root()
+	for _, line := range strings.Split(c.ExampleText(), "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "$ ")
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != root.Name() {
+			continue
+		}
+
+		cmd, args, err := root.Find(fields[1:])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid example for %q: %q: %w", c.CommandPath(), line, err))
+			continue
+		}
+
+		if cmd.DisableFlagParsing {
+			continue
+		}
+
+		cmd.mergePersistentFlags()
+		for _, name := range unknownFlagsInArgs(cmd, args) {
+			errs = append(errs, fmt.Errorf("invalid example for %q: %q: unknown flag: %s", c.CommandPath(), line, name))
+		}
+	}
+
+	return errs
+}
+
+// unknownFlagsInArgs returns the flags referenced in args that are not defined in cmd,
+// nor are the help and version flags added when executing it. Flag values are not
+// parsed, which allows checking args without modifying cmd.
+func unknownFlagsInArgs(cmd *Command, args []string) []string {
+	var unknown []string
+
+	fs := cmd.Flags()
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return unknown
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg[2:], "=")
+			if f := fs.Lookup(name); (f != nil && !f.ShorthandOnly) || addsDefaultFlag(cmd, name) {
+				continue
+			}
+			if positive, ok := strings.CutPrefix(name, "no-"); ok {
+				if f := fs.Lookup(positive); f != nil && f.AddNegative {
+					continue
+				}
+			}
+			unknown = append(unknown, "--"+name)
+		case len(arg) > 1 && arg[0] == '-':
+			if name := unknownShorthandInArg(cmd, arg[1:]); name != "" {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+
+	return unknown
+}
+
+// unknownShorthandInArg returns the first unknown shorthand flag in a series of
+// shorthands such as "vvf", or an empty string if there is none. Checking stops
+// at the first flag taking a value, as the rest of the series is its value.
+func unknownShorthandInArg(cmd *Command, shorthands string) string {
+	fs := cmd.Flags()
+	for _, char := range shorthands {
+		if char == '=' {
+			return ""
+		}
+
+		f := fs.ShorthandLookup(char)
+		if f == nil && ((char == 'h' && addsDefaultFlag(cmd, "help")) || (char == 'v' && addsDefaultFlag(cmd, "version"))) {
+			// The default help and version flags are boolean.
+			continue
+		}
+		if f == nil {
+			// zflag also accepts single character long flags with a single dash.
+			if f = fs.Lookup(string(char)); f != nil && f.Shorthand > 0 {
+				f = nil
+			}
+		}
+		if f == nil {
+			return fmt.Sprintf("-%c", char)
+		}
+
+		if _, isBool := f.Value.(zflag.BoolFlag); !isBool {
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// addsDefaultFlag reports whether executing cmd adds the default flag name,
+// help or version, as it does not define it.
+func addsDefaultFlag(cmd *Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil {
+		return false
+	}
+
+	switch name {
+	case "help":
+		return !cmd.isMinimal()
+	case "version":
+		return cmd.Version != ""
+	default:
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package zulu_test
+
+import (
+	"testing"
+
+	"github.com/zulucmd/zflag/v2"
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestValidateVerifyExamples(t *testing.T) {
+	rootCmd := &zulu.Command{Use: "root", RunE: noopRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output", zflag.OptShorthand('v'))
+	childCmd := &zulu.Command{
+		Use:            "child",
+		RunE:           noopRun,
+		VerifyExamples: true,
+		Example: `  # Print the output as json
+  root child --output json -v
+
+  $ root child -vo=yaml --no-color --help`,
+	}
+	childCmd.Flags().String("output", "", "output format", zflag.OptShorthand('o'))
+	childCmd.Flags().Bool("color", true, "colorize the output", zflag.OptAddNegative())
+	rootCmd.AddCommand(childCmd)
+
+	testutil.AssertNil(t, rootCmd.Validate())
+
+	childCmd.Example += "\n  root child --format json -x"
+	err := rootCmd.Validate()
+	testutil.AssertErrf(t, err, "expected an error for an example with removed flags")
+	testutil.AssertContains(t, err.Error(), `invalid example for "root child": "root child --format json -x": unknown flag: --format`)
+	testutil.AssertContains(t, err.Error(), `invalid example for "root child": "root child --format json -x": unknown flag: -x`)
+}
+
+func TestValidateVerifyExamplesDisabled(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:     "root",
+		RunE:    noopRun,
+		Example: "root --removed",
+	}
+
+	testutil.AssertNil(t, rootCmd.Validate())
+
+	rootCmd.VerifyExamples = true
+	testutil.AssertErrf(t, rootCmd.Validate(), "expected an error for an example with a removed flag")
+}
+
+func TestValidateVerifyExamplesDefaultFlags(t *testing.T) {
+	rootCmd := &zulu.Command{
+		Use:            "root",
+		Version:        "1.0.0",
+		RunE:           noopRun,
+		VerifyExamples: true,
+		Example:        "root --help\nroot -hv\nroot --version",
+	}
+
+	testutil.AssertNil(t, rootCmd.Validate())
+	testutil.AssertEqualf(t, true, rootCmd.Flags().Lookup("help") == nil, "Validate should not add the help flag")
+	testutil.AssertEqualf(t, true, rootCmd.Flags().Lookup("version") == nil, "Validate should not add the version flag")
+
+	rootCmd.Version = ""
+	err := rootCmd.Validate()
+	testutil.AssertErrf(t, err, "expected an error for the version flag of a command without a version")
+	testutil.AssertContains(t, err.Error(), `unknown flag: -v`)
+	testutil.AssertContains(t, err.Error(), `unknown flag: --version`)
+}
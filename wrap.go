@@ -0,0 +1,98 @@
+package zulu
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/zulucmd/zflag/v2"
+)
+
+// defaultTerminalWidth is the width the usage and help are wrapped to when
+// the width of the terminal can't be detected.
+const defaultTerminalWidth = 80
+
+// TerminalWidth returns the width the help of the command is wrapped to: the
+// number of columns of its output if it is a terminal, else the COLUMNS
+// environment variable if it is set, else 80.
+func (c *Command) TerminalWidth() int {
+	return terminalWidth(c.OutOrStdout())
+}
+
+// terminalWidth returns the width the output written to w is wrapped to, see
+// TerminalWidth.
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if cols := terminalColumns(f); cols > 0 {
+			return cols
+		}
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return defaultTerminalWidth
+}
+
+// wrapTemplateFuncs returns the wrapping template functions of the command
+// for the output written to w.
+func (c *Command) wrapTemplateFuncs(w io.Writer) template.FuncMap {
+	width := terminalWidth(w)
+	return template.FuncMap{
+		"wrap": func(indent int, s string) string {
+			return wrapText(s, width, indent)
+		},
+		"wrapFlags": func(fs *zflag.FlagSet, group string) string {
+			return c.flagUsagesForGroupWrapped(fs, group, width)
+		},
+	}
+}
+
+// flagUsagesForGroupWrapped is the same as FlagUsagesForGroup, but wraps the
+// usages of the flags under their column to fit in width. The usages rendered
+// by a flag usage formatter are not wrapped.
+func (c *Command) flagUsagesForGroupWrapped(fs *zflag.FlagSet, group string, width int) string {
+	for p := c; p != nil; p = p.Parent() {
+		if p.flagUsageFormatter != nil {
+			return c.FlagUsagesForGroup(fs, group)
+		}
+	}
+	return fs.FlagUsagesForGroupWrapped(group, width)
+}
+
+// wrapText wraps the lines of s longer than width at the spaces between words,
+// indenting the continuation lines by indent spaces. The words longer than the
+// available width are left on a line of their own.
+func wrapText(s string, width, indent int) string {
+	if width-indent <= 0 {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if utf8.RuneCountInString(line) <= width {
+			continue
+		}
+
+		var b strings.Builder
+		lineLen := 0
+		for j, word := range strings.Split(line, " ") {
+			wordLen := utf8.RuneCountInString(word)
+			if j > 0 {
+				if lineLen+1+wordLen > width && lineLen > indent {
+					b.WriteString("\n" + strings.Repeat(" ", indent))
+					lineLen = indent
+				} else {
+					b.WriteString(" ")
+					lineLen++
+				}
+			}
+			b.WriteString(word)
+			lineLen += wordLen
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,38 @@
+package zulu_test
+
+import (
+	"testing"
+
+	"github.com/zulucmd/zulu/v2"
+	"github.com/zulucmd/zulu/v2/internal/testutil"
+)
+
+func TestWrapUsage(t *testing.T) {
+	t.Setenv("COLUMNS", "50")
+
+	rootCmd := &zulu.Command{
+		Use:  "root",
+		Long: "A long description of the root command which does not fit on a narrow terminal.",
+		RunE: noopRun,
+	}
+	rootCmd.Flags().String("name", "", "the name used by the command when it greets someone by their name")
+
+	testutil.AssertEqual(t, 50, rootCmd.TerminalWidth())
+
+	output, err := executeCommand(rootCmd, "--help")
+	testutil.AssertNilf(t, err, "Unexpected error: %v", err)
+
+	expected := `A long description of the root command which does
+not fit on a narrow terminal.
+
+Usage:
+  root [flags]
+
+Flags:
+  -h, --help          help for root
+      --name string   the name used by the
+                      command when it greets
+                      someone by their name
+`
+	testutil.AssertEqual(t, expected, rmCarriageRet(output))
+}
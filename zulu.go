@@ -18,28 +18,48 @@ package zulu
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
 	"unicode"
 )
 
-var templateFuncs = template.FuncMap{
+// builtinTemplateFuncs are the template functions the default templates rely
+// on which don't depend on the output, which AddTemplateFunc refuses to override.
+var builtinTemplateFuncs = template.FuncMap{
 	"trim":                    strings.TrimSpace,
 	"trimRightSpace":          trimRightSpace,
 	"trimTrailingWhitespaces": trimRightSpace,
 	"rpad":                    rpad,
 }
 
+// builtinOutputTemplateFuncs are the names of the template functions the default
+// templates rely on which depend on the output, see colorTemplateFuncs and
+// wrapTemplateFuncs. AddTemplateFunc refuses to override them too.
+var builtinOutputTemplateFuncs = []string{"bold", "cyan", "colorFlags", "wrap", "wrapFlags"}
+
+var templateFuncs = maps.Clone(builtinTemplateFuncs)
+
 // EnablePrefixMatching allows to set an automatic prefix matching. The automatic prefix matching can be a
 // dangerous thing to automatically enable in CLI tools.
 // Set this to true to enable it.
 var EnablePrefixMatching = false
 
+// EnableCaseInsensitive allows case-insensitive commands names and aliases, which is turned off by default.
+// Set this to true to enable it.
+var EnableCaseInsensitive = false
+
 // EnableCommandSorting controls sorting of the slice of commands, which is turned on by default.
 // To disable sorting, set it to false.
 var EnableCommandSorting = true
 
+// DefaultSortFlags controls whether the flag sets of the commands are sorted, which is turned on by default.
+// To list the flags in the order they were declared, set it to false before the flags are created.
+// Commands can still change it with the SortFlags field of their flag sets.
+var DefaultSortFlags = true
+
 // MousetrapHelpText enables an information splash screen on Windows
 // if the CLI is started from explorer.exe.
 // To disable the mousetrap, just set this variable to blank string ("").
@@ -55,20 +75,61 @@ You need to open cmd.exe and run it from there.
 // Works only on Microsoft Windows.
 var MousetrapDisplayDuration = 5 * time.Second
 
-// AddTemplateFunc adds a template function that's available to Usage and Help
-// template generation.
+// AddTemplateFunc adds a template function that's available to Usage, Help
+// and Version template generation for all commands. It panics if name is one
+// of the built-in functions, which ForceAddTemplateFunc overrides.
 func AddTemplateFunc(name string, tmplFunc any) {
+	if isBuiltinTemplateFunc(name) {
+		panic(fmt.Sprintf("template function %q is built in, use ForceAddTemplateFunc to override it", name))
+	}
 	templateFuncs[name] = tmplFunc
 }
 
-// AddTemplateFuncs adds multiple template functions that are available to Usage and
-// Help template generation.
+// AddTemplateFuncs adds multiple template functions that are available to Usage,
+// Help and Version template generation for all commands. It panics if any of
+// them is one of the built-in functions, without adding any.
 func AddTemplateFuncs(tmplFuncs template.FuncMap) {
+	for k := range tmplFuncs {
+		if isBuiltinTemplateFunc(k) {
+			panic(fmt.Sprintf("template function %q is built in, use ForceAddTemplateFunc to override it", k))
+		}
+	}
 	for k, v := range tmplFuncs {
 		AddTemplateFunc(k, v)
 	}
 }
 
+// isBuiltinTemplateFunc reports whether name is one of the template functions
+// the default templates rely on.
+func isBuiltinTemplateFunc(name string) bool {
+	_, ok := builtinTemplateFuncs[name]
+	return ok || slices.Contains(builtinOutputTemplateFuncs, name)
+}
+
+// ForceAddTemplateFunc is the same as AddTemplateFunc, but also overrides the
+// built-in functions.
+func ForceAddTemplateFunc(name string, tmplFunc any) {
+	templateFuncs[name] = tmplFunc
+}
+
+// commandNameMatches reports whether the command name or alias s is t,
+// ignoring the case if EnableCaseInsensitive is set.
+func commandNameMatches(s string, t string) bool {
+	if EnableCaseInsensitive {
+		return strings.EqualFold(s, t)
+	}
+	return s == t
+}
+
+// commandNameHasPrefix reports whether the command name or alias s starts with
+// prefix, ignoring the case if EnableCaseInsensitive is set.
+func commandNameHasPrefix(s string, prefix string) bool {
+	if EnableCaseInsensitive {
+		return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+	}
+	return strings.HasPrefix(s, prefix)
+}
+
 func trimRightSpace(s string) string {
 	return strings.TrimRightFunc(s, unicode.IsSpace)
 }
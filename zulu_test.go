@@ -1,6 +1,7 @@
 package zulu_test
 
 import (
+	"strings"
 	"testing"
 	"text/template"
 
@@ -22,3 +23,58 @@ func TestAddTemplateFunctions(t *testing.T) {
 		t.Errorf("Expected UsageString: %v\nGot: %v", expected, got)
 	}
 }
+
+func TestAddTemplateFunctionsBuiltin(t *testing.T) {
+	assertPanics := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected %s to panic when overriding a built-in function", name)
+			}
+		}()
+		f()
+	}
+
+	assertPanics("AddTemplateFunc", func() {
+		zulu.AddTemplateFunc("rpad", func(s string, _ int) string { return s })
+	})
+	assertPanics("AddTemplateFuncs", func() {
+		zulu.AddTemplateFuncs(template.FuncMap{
+			"notBuiltin": func() string { return "" },
+			"trim":       func(s string) string { return s },
+		})
+	})
+
+	c := &zulu.Command{}
+	c.SetUsageTemplate(`{{ rpad "a" 3 }}|{{ trim " b " }}`)
+	if got, expected := c.UsageString(), "a  |b"; got != expected {
+		t.Errorf("Expected UsageString: %v\nGot: %v", expected, got)
+	}
+
+	zulu.ForceAddTemplateFunc("trim", func(s string) string { return "forced" })
+	defer zulu.ForceAddTemplateFunc("trim", strings.TrimSpace)
+	if got, expected := c.UsageString(), "a  |forced"; got != expected {
+		t.Errorf("Expected UsageString: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestAddTemplateFuncOverColorFunc(t *testing.T) {
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected AddTemplateFunc to panic when overriding a built-in function")
+			}
+		}()
+		zulu.AddTemplateFunc("bold", func(s string) string { return s })
+	}()
+
+	zulu.ForceAddTemplateFunc("bold", func(s string) string { return "**" + s + "**" })
+	defer zulu.RemoveTemplateFunc("bold")
+
+	c := &zulu.Command{Use: "root"}
+	c.SetColorMode(zulu.ColorModeAlways)
+	c.SetUsageTemplate(`{{ bold "a" }}|{{ cyan "b" }}`)
+	if got, expected := c.UsageString(), "**a**|\x1b[36mb\x1b[0m"; got != expected {
+		t.Errorf("Expected UsageString: %q\nGot: %q", expected, got)
+	}
+}